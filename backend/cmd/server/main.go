@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
 
 	"propets/backend/internal/app"
 )
@@ -15,6 +17,20 @@ func main() {
 	}
 	defer server.Close()
 
+	// "server maintenance" runs one cleanup pass and exits, for cron or a
+	// manual run when the background runner is disabled.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "maintenance":
+			if err := server.RunMaintenance(context.Background()); err != nil {
+				log.Fatal(err)
+			}
+			return
+		default:
+			log.Fatalf("unknown command %q (usage: server [maintenance])", os.Args[1])
+		}
+	}
+
 	if err := server.ListenAndServe(); err != nil {
 		log.Fatal(err)
 	}
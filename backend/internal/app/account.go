@@ -0,0 +1,173 @@
+package app
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// codeAlphabet leaves out characters that are easy to misread when a code is
+// passed on by hand.
+const (
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	resetCodeLen = 12
+)
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+	// RefreshToken identifies the session to keep signed in.
+	RefreshToken string `json:"refreshToken"`
+}
+
+type resetPasswordRequest struct {
+	Phone       string `json:"phone"`
+	Code        string `json:"code"`
+	NewPassword string `json:"newPassword"`
+}
+
+// handleChangePassword sets a new password after checking the current one
+// and signs the user out of every other session. The caller's access token is
+// voided too; the kept refresh token gets a new one.
+func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.NewPassword = strings.TrimSpace(req.NewPassword)
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		writeErr(w, http.StatusBadRequest, "currentPassword and newPassword are required")
+		return
+	}
+
+	authUser := authUserFromContext(r.Context())
+	user, err := findUserByID(r.Context(), s.db, authUser.ID)
+	if err != nil {
+		writeErr(w, http.StatusUnauthorized, "user not found")
+		return
+	}
+	// A stolen access token must not allow guessing the current password
+	// faster than guessing it at login, so both share the lockout counters.
+	if !s.checkLoginLockout(w, r, user.Phone) {
+		return
+	}
+	if !CheckPassword(user.PasswordHash, strings.TrimSpace(req.CurrentPassword)) {
+		s.recordLoginFailure(r, user.Phone)
+		writeErr(w, http.StatusForbidden, "current password is incorrect")
+		return
+	}
+	if _, err := clearLoginAttempts(r.Context(), s.db, loginScopePhone, user.Phone); err != nil {
+		log.Printf("failed to reset login failures: %v", err)
+	}
+
+	hash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
+	if err := changeUserPassword(r.Context(), s.db, user.ID, hash, strings.TrimSpace(req.RefreshToken)); err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+	s.tokenStates.forget(user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCreatePasswordReset issues a one-time reset code for a user. The
+// admin passes it on; the user redeems it at POST /api/auth/reset.
+func (s *Server) handleCreatePasswordReset(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid user id")
+	if !ok {
+		return
+	}
+	if _, err := findUserByID(r.Context(), s.db, int64(id)); err != nil {
+		handleUserError(w, err, "failed to create reset code")
+		return
+	}
+
+	code, err := randomCode(resetCodeLen)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to create reset code")
+		return
+	}
+	expiresAt := time.Now().Add(s.cfg.PasswordResetTTL)
+	admin := authUserFromContext(r.Context())
+	if err := createPasswordResetCode(r.Context(), s.db, int64(id), admin.ID, code, expiresAt); err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to create reset code")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"code":      code,
+		"expiresAt": expiresAt.Format(time.RFC3339),
+	})
+}
+
+// handleResetPassword redeems a reset code. Unknown phones and bad codes get
+// the same answer so the endpoint does not reveal which accounts exist.
+func (s *Server) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Phone = normalizePhone(req.Phone)
+	req.Code = normalizeCode(req.Code)
+	req.NewPassword = strings.TrimSpace(req.NewPassword)
+	if req.Phone == "" || req.Code == "" || req.NewPassword == "" {
+		writeErr(w, http.StatusBadRequest, "phone, code and newPassword are required")
+		return
+	}
+
+	user, err := findUserByPhone(r.Context(), s.db, req.Phone)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeErr(w, http.StatusBadRequest, errInvalidResetCode.Error())
+		return
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to reset password")
+		return
+	}
+
+	hash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
+	if err := resetPasswordWithCode(r.Context(), s.db, user.ID, req.Code, hash, time.Now()); err != nil {
+		if errors.Is(err, errInvalidResetCode) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, "failed to reset password")
+		return
+	}
+	s.tokenStates.forget(user.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// randomCode returns a code of length characters from codeAlphabet.
+func randomCode(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// normalizeCode accepts reset and invite codes typed in lower case or split
+// by spaces and dashes.
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"propets/backend/internal/repository"
+	"propets/backend/internal/service"
+)
+
+type budgetSetRequest struct {
+	MonthlyAmount string `json:"monthlyAmount"`
+}
+
+type budgetsResponse struct {
+	Items []service.CategoryBudget `json:"items"`
+}
+
+func (s *Server) handleListBudgets(w http.ResponseWriter, r *http.Request) {
+	items, err := s.budgets.ListBudgets(r.Context())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list budgets")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, budgetsResponse{Items: items})
+}
+
+func (s *Server) handleSetBudget(w http.ResponseWriter, r *http.Request) {
+	var req budgetSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	err := s.budgets.SetBudget(r.Context(), service.SetBudgetInput{
+		ActorUserID:   uint64(user.ID),
+		Category:      r.PathValue("category"),
+		MonthlyAmount: req.MonthlyAmount,
+	})
+	if err != nil {
+		if isValidationErr(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, "failed to save budget")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteBudget(w http.ResponseWriter, r *http.Request) {
+	if err := s.budgets.DeleteBudget(r.Context(), r.PathValue("category")); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrBudgetNotFound):
+			writeErr(w, http.StatusNotFound, "budget not found")
+		case isValidationErr(err):
+			writeErr(w, http.StatusBadRequest, err.Error())
+		default:
+			writeErr(w, http.StatusInternalServerError, "failed to delete budget")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
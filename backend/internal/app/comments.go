@@ -0,0 +1,160 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+	"propets/backend/internal/service"
+)
+
+type commentCreateRequest struct {
+	Body     string `json:"body"`
+	ParentID uint64 `json:"parentId"`
+}
+
+type commentModerateRequest struct {
+	Resolved *bool `json:"resolved"`
+	Hidden   *bool `json:"hidden"`
+}
+
+type commentResponseItem struct {
+	ID         uint64  `json:"id"`
+	AuthorID   uint64  `json:"author_id"`
+	Body       string  `json:"body"`
+	Hidden     bool    `json:"hidden"`
+	CreatedAt  string  `json:"created_at"`
+	Resolved   bool    `json:"resolved"`
+	ResolvedBy *uint64 `json:"resolved_by,omitempty"`
+	ResolvedAt *string `json:"resolved_at,omitempty"`
+	// Replies is only set on threads.
+	Replies []commentResponseItem `json:"replies,omitempty"`
+}
+
+func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
+	entryID, ok := parsePathID(w, r, "invalid entry id")
+	if !ok {
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	comments, err := s.comments.List(r.Context(), entryID, user.can(permAuditRead))
+	if err != nil {
+		handleCommentError(w, err, "failed to list comments")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": toCommentThreads(comments)})
+}
+
+func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	entryID, ok := parsePathID(w, r, "invalid entry id")
+	if !ok {
+		return
+	}
+	var req commentCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	id, err := s.comments.Post(r.Context(), service.PostCommentInput{
+		EntryID:  entryID,
+		AuthorID: uint64(user.ID),
+		Keeper:   user.can(permLedgerWrite),
+		ParentID: req.ParentID,
+		Body:     req.Body,
+	})
+	if err != nil {
+		handleCommentError(w, err, "failed to post comment")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"id": id})
+}
+
+func (s *Server) handleModerateComment(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid comment id")
+	if !ok {
+		return
+	}
+	var req commentModerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	err := s.comments.Moderate(r.Context(), service.ModerateCommentInput{
+		CommentID: id,
+		ActorID:   uint64(user.ID),
+		Resolved:  req.Resolved,
+		Hidden:    req.Hidden,
+	})
+	if err != nil {
+		handleCommentError(w, err, "failed to update comment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleCommentError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrLedgerEntryNotFound):
+		writeErr(w, http.StatusNotFound, "entry not found")
+	case errors.Is(err, repository.ErrCommentNotFound):
+		writeErr(w, http.StatusNotFound, "comment not found")
+	case errors.Is(err, service.ErrCommentReplyForbidden):
+		writeErr(w, http.StatusForbidden, err.Error())
+	default:
+		if isValidationErr(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+// toCommentThreads nests replies under their thread. Replies whose thread is
+// not in the list, because it was hidden, are dropped with it.
+func toCommentThreads(comments []model.LedgerComment) []commentResponseItem {
+	threads := make([]commentResponseItem, 0)
+	index := make(map[uint64]int)
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			index[comment.ID] = len(threads)
+			threads = append(threads, toCommentResponseItem(comment))
+		}
+	}
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			continue
+		}
+		if i, ok := index[*comment.ParentID]; ok {
+			threads[i].Replies = append(threads[i].Replies, toCommentResponseItem(comment))
+		}
+	}
+	return threads
+}
+
+func toCommentResponseItem(comment model.LedgerComment) commentResponseItem {
+	out := commentResponseItem{
+		ID:         comment.ID,
+		AuthorID:   comment.AuthorID,
+		Body:       comment.Body,
+		Hidden:     comment.Hidden,
+		CreatedAt:  comment.CreatedAt.Format(time.RFC3339),
+		Resolved:   comment.ResolvedAt != nil,
+		ResolvedBy: comment.ResolvedBy,
+	}
+	if comment.ResolvedAt != nil {
+		resolvedAt := comment.ResolvedAt.Format(time.RFC3339)
+		out.ResolvedAt = &resolvedAt
+	}
+	return out
+}
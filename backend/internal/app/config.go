@@ -1,45 +1,174 @@
 package app
 
 import (
+	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// devJWTSecret is the JWT_SECRET of local setups. It is never kept as a
+// verification key next to JWT_KEYS.
+const devJWTSecret = "dev_jwt_secret_change_me"
+
 type Config struct {
-	AppPort          string
-	DBHost           string
-	DBPort           string
-	DBName           string
-	DBUser           string
-	DBPassword       string
-	JWTSecret        string
+	AppPort    string
+	DBHost     string
+	DBPort     string
+	DBName     string
+	DBUser     string
+	DBPassword string
+	JWTSecret  string
+	// JWTKeys lists signing keys as kid:algorithm:material entries separated
+	// by semicolons (see ParseJWTKeys); when empty JWTSecret is the only key,
+	// otherwise it still verifies unless JWTKeys has a "default" entry.
+	// JWTSigningKeyID picks the key new tokens are signed with, the others
+	// only verify.
+	JWTKeys          string
+	JWTSigningKeyID  string
 	AccessTokenTTL   time.Duration
 	RefreshTokenTTL  time.Duration
 	AdminInitPhone   string
 	AdminInitPass    string
 	AdminInitEnabled bool
+	// RecurringInterval is how often recurring templates are materialized;
+	// zero disables the scheduler.
+	RecurringInterval time.Duration
+	// ApprovalThreshold is the amount above which expenses, edits and
+	// deletions wait for a second admin; empty disables dual approval.
+	ApprovalThreshold string
+	// PublicModeEnabled serves the read-only /api/public endpoints that need
+	// no login. PublicDonorPrivacy is full, mask or hide.
+	PublicModeEnabled     bool
+	PublicDonorPrivacy    string
+	PublicRateLimitPerMin int
+	// TrustedProxies lists the addresses, as CIDRs or bare IPs separated by
+	// commas, of the proxies whose X-Real-IP header names the client, such as
+	// the frontend nginx. Requests from anywhere else are keyed by their own
+	// address. Empty trusts no proxy.
+	TrustedProxies string
+	// Timezone is the IANA zone the organization keeps its books in. Ledger
+	// dates are stored as wall time in this zone and grouped into its months.
+	Timezone string
+	// PasswordResetTTL is how long an admin-issued reset code stays valid.
+	PasswordResetTTL time.Duration
+	// SMSProvider sends login codes: "log" writes them to the process log,
+	// "file" appends them to SMSFilePath.
+	SMSProvider string
+	SMSFilePath string
+	SMSCodeTTL  time.Duration
+	// SMSSendsPerIPPerHour caps the codes one client IP may request per
+	// hour across all phone numbers; zero turns the cap off.
+	SMSSendsPerIPPerHour int
+	// LoginMaxFailuresPerPhone and LoginMaxFailuresPerIP failed logins within
+	// LoginLockout lock the phone number or client IP out for LoginLockout,
+	// doubling with each repeat.
+	LoginMaxFailuresPerPhone int
+	LoginMaxFailuresPerIP    int
+	LoginLockout             time.Duration
+	// TokenStateCacheTTL is how long withAuth trusts a cached token version
+	// and account status; zero looks them up on every request.
+	TokenStateCacheTTL time.Duration
+	// RegistrationInviteRequired makes a valid invite code mandatory for new
+	// accounts, by password or by SMS code.
+	RegistrationInviteRequired bool
+	// RegistrationApprovalRequired puts accounts registered without an invite
+	// code in a pending state until an admin approves them.
+	RegistrationApprovalRequired bool
+	// MaintenanceInterval is how often expired tokens, codes, idempotency
+	// keys, login counters and security events are purged; zero disables the
+	// background runner (the maintenance subcommand still works). Refresh
+	// tokens and one-time codes are kept for RefreshTokenRetention after they
+	// expire or are revoked, idempotency keys for IdempotencyKeyTTL after
+	// they are created and security events for SecurityEventRetention, zero
+	// keeping them forever.
+	MaintenanceInterval    time.Duration
+	RefreshTokenRetention  time.Duration
+	IdempotencyKeyTTL      time.Duration
+	SecurityEventRetention time.Duration
 }
 
 func LoadConfig() Config {
 	return Config{
-		AppPort:          getEnv("APP_PORT", "8080"),
-		DBHost:           getEnv("DB_HOST", "127.0.0.1"),
-		DBPort:           getEnv("DB_PORT", "3306"),
-		DBName:           getEnv("DB_NAME", "pet_rescue"),
-		DBUser:           getEnv("DB_USER", "pet_user"),
-		DBPassword:       getEnv("DB_PASSWORD", "pet_password"),
-		JWTSecret:        getEnv("JWT_SECRET", "dev_jwt_secret_change_me"),
-		AccessTokenTTL:   time.Duration(getEnvInt("ACCESS_TOKEN_TTL_MIN", 15)) * time.Minute,
-		RefreshTokenTTL:  time.Duration(getEnvInt("REFRESH_TOKEN_TTL_HOUR", 168)) * time.Hour,
-		AdminInitPhone:   os.Getenv("ADMIN_INIT_PHONE"),
-		AdminInitPass:    os.Getenv("ADMIN_INIT_PASSWORD"),
-		AdminInitEnabled: getEnvBool("ADMIN_INIT_ENABLED", false),
+		AppPort:                      getEnv("APP_PORT", "8080"),
+		DBHost:                       getEnv("DB_HOST", "127.0.0.1"),
+		DBPort:                       getEnv("DB_PORT", "3306"),
+		DBName:                       getEnv("DB_NAME", "pet_rescue"),
+		DBUser:                       getEnv("DB_USER", "pet_user"),
+		DBPassword:                   getEnv("DB_PASSWORD", "pet_password"),
+		JWTSecret:                    getEnv("JWT_SECRET", devJWTSecret),
+		JWTKeys:                      os.Getenv("JWT_KEYS"),
+		JWTSigningKeyID:              os.Getenv("JWT_SIGNING_KEY_ID"),
+		AccessTokenTTL:               time.Duration(getEnvInt("ACCESS_TOKEN_TTL_MIN", 15)) * time.Minute,
+		RefreshTokenTTL:              time.Duration(getEnvInt("REFRESH_TOKEN_TTL_HOUR", 168)) * time.Hour,
+		AdminInitPhone:               os.Getenv("ADMIN_INIT_PHONE"),
+		AdminInitPass:                os.Getenv("ADMIN_INIT_PASSWORD"),
+		AdminInitEnabled:             getEnvBool("ADMIN_INIT_ENABLED", false),
+		RecurringInterval:            time.Duration(getEnvInt("RECURRING_INTERVAL_MIN", 60)) * time.Minute,
+		ApprovalThreshold:            os.Getenv("DUAL_APPROVAL_THRESHOLD"),
+		PublicModeEnabled:            getEnvBool("PUBLIC_MODE_ENABLED", false),
+		PublicDonorPrivacy:           getEnv("PUBLIC_DONOR_PRIVACY", "mask"),
+		PublicRateLimitPerMin:        getEnvInt("PUBLIC_RATE_LIMIT_PER_MIN", 60),
+		TrustedProxies:               getEnv("TRUSTED_PROXIES", ""),
+		Timezone:                     getEnv("ORG_TIMEZONE", "Asia/Shanghai"),
+		PasswordResetTTL:             time.Duration(getEnvInt("PASSWORD_RESET_TTL_MIN", 30)) * time.Minute,
+		SMSProvider:                  getEnv("SMS_PROVIDER", "log"),
+		SMSFilePath:                  os.Getenv("SMS_FILE_PATH"),
+		SMSCodeTTL:                   time.Duration(getEnvInt("SMS_CODE_TTL_MIN", 5)) * time.Minute,
+		SMSSendsPerIPPerHour:         getEnvInt("SMS_SENDS_PER_IP_PER_HOUR", 10),
+		LoginMaxFailuresPerPhone:     getEnvInt("LOGIN_MAX_FAILURES_PER_PHONE", 5),
+		LoginMaxFailuresPerIP:        getEnvInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+		LoginLockout:                 time.Duration(getEnvInt("LOGIN_LOCKOUT_MIN", 15)) * time.Minute,
+		TokenStateCacheTTL:           time.Duration(getEnvInt("TOKEN_STATE_CACHE_TTL_SEC", 30)) * time.Second,
+		RegistrationInviteRequired:   getEnvBool("REGISTRATION_INVITE_REQUIRED", false),
+		RegistrationApprovalRequired: getEnvBool("REGISTRATION_APPROVAL_REQUIRED", false),
+		MaintenanceInterval:          time.Duration(getEnvInt("MAINTENANCE_INTERVAL_MIN", 60)) * time.Minute,
+		RefreshTokenRetention:        time.Duration(getEnvInt("REFRESH_TOKEN_RETENTION_DAY", 30)) * 24 * time.Hour,
+		IdempotencyKeyTTL:            time.Duration(getEnvInt("IDEMPOTENCY_KEY_TTL_DAY", 30)) * 24 * time.Hour,
+		SecurityEventRetention:       time.Duration(getEnvInt("SECURITY_EVENT_RETENTION_DAY", 365)) * 24 * time.Hour,
 	}
 }
 
 func (c Config) DSN() string {
-	return c.DBUser + ":" + c.DBPassword + "@tcp(" + c.DBHost + ":" + c.DBPort + ")/" + c.DBName + "?parseTime=true&charset=utf8mb4&loc=Asia%2FShanghai"
+	return c.DBUser + ":" + c.DBPassword + "@tcp(" + c.DBHost + ":" + c.DBPort + ")/" + c.DBName + "?parseTime=true&charset=utf8mb4&loc=" + url.QueryEscape(c.Timezone)
+}
+
+// Location loads the organization timezone.
+func (c Config) Location() (*time.Location, error) {
+	return time.LoadLocation(c.Timezone)
+}
+
+// TrustedProxyNets parses TrustedProxies; a bare IP is a single-address
+// network.
+func (c Config) TrustedProxyNets() ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0)
+	for _, item := range strings.Split(c.TrustedProxies, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR", item)
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
 }
 
 func getEnv(key, fallback string) string {
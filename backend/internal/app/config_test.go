@@ -0,0 +1,23 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigTimezone(t *testing.T) {
+	cfg := Config{DBUser: "u", DBPassword: "p", DBHost: "h", DBPort: "3306", DBName: "d", Timezone: "America/Los_Angeles"}
+
+	if dsn := cfg.DSN(); !strings.HasSuffix(dsn, "&loc=America%2FLos_Angeles") {
+		t.Errorf("DSN %q does not carry the organization timezone", dsn)
+	}
+	loc, err := cfg.Location()
+	if err != nil || loc.String() != "America/Los_Angeles" {
+		t.Errorf("Location() = %v, %v", loc, err)
+	}
+
+	cfg.Timezone = "Mars/Olympus"
+	if _, err := cfg.Location(); err == nil {
+		t.Error("expected an error for an unknown timezone")
+	}
+}
@@ -0,0 +1,213 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+	"propets/backend/internal/service"
+)
+
+type donationReportCreateRequest struct {
+	Donor         string `json:"donor"`
+	Group         string `json:"group"`
+	Amount        string `json:"amount"`
+	DonatedAt     string `json:"donatedAt"`
+	ScreenshotURL string `json:"screenshotUrl"`
+}
+
+type donationReportResponseItem struct {
+	ID            uint64      `json:"id"`
+	ReporterID    uint64      `json:"reporter_id"`
+	Donor         string      `json:"donor"`
+	Group         string      `json:"group"`
+	Amount        model.Money `json:"amount"`
+	DonatedAt     string      `json:"donated_at"`
+	ScreenshotURL string      `json:"screenshot_url"`
+	Status        string      `json:"status"`
+	ReviewedBy    *uint64     `json:"reviewed_by"`
+	ReviewedAt    *string     `json:"reviewed_at"`
+	ReviewNote    string      `json:"review_note"`
+	EntryID       *uint64     `json:"entry_id"`
+	CreatedAt     string      `json:"created_at"`
+}
+
+func (s *Server) handleCreateDonationReport(w http.ResponseWriter, r *http.Request) {
+	var req donationReportCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	id, err := s.donationReports.Submit(r.Context(), service.SubmitDonationReportInput{
+		ReporterID:    uint64(user.ID),
+		Donor:         req.Donor,
+		Group:         req.Group,
+		Amount:        req.Amount,
+		DonatedAt:     req.DonatedAt,
+		ScreenshotURL: req.ScreenshotURL,
+	})
+	if err != nil {
+		if isValidationErr(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, "failed to submit donation report")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"id": id, "status": model.DonationReportStatusPending})
+}
+
+func (s *Server) handleListDonationReports(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePositiveQueryInt(r, "page")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	pageSize, err := parsePositiveQueryInt(r, "pageSize")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	input := service.ListDonationReportsInput{
+		Status:   r.URL.Query().Get("status"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if !user.can(permAuditRead) {
+		input.ReporterID = uint64(user.ID)
+	}
+
+	result, err := s.donationReports.List(r.Context(), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidDonationReportStatus):
+			writeErr(w, http.StatusBadRequest, "invalid status")
+		case errors.Is(err, service.ErrInvalidPage):
+			writeErr(w, http.StatusBadRequest, "invalid page")
+		case errors.Is(err, service.ErrInvalidPageSize):
+			writeErr(w, http.StatusBadRequest, "invalid pageSize")
+		default:
+			writeErr(w, http.StatusInternalServerError, "failed to list donation reports")
+		}
+		return
+	}
+
+	items := make([]donationReportResponseItem, 0, len(result.Items))
+	for _, item := range result.Items {
+		items = append(items, toDonationReportResponseItem(item))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"items":       items,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total":       result.Total,
+		"total_pages": result.TotalPages,
+	})
+}
+
+func (s *Server) handleGetDonationReport(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid donation report id")
+	if !ok {
+		return
+	}
+
+	item, err := s.donationReports.Get(r.Context(), id)
+	if err != nil {
+		handleDonationReportError(w, err, "failed to fetch donation report")
+		return
+	}
+	user := authUserFromContext(r.Context())
+	if !user.can(permAuditRead) && item.ReporterID != uint64(user.ID) {
+		writeErr(w, http.StatusNotFound, "donation report not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toDonationReportResponseItem(item))
+}
+
+func (s *Server) handleConfirmDonationReport(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid donation report id")
+	if !ok {
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	entryID, err := s.donationReports.Confirm(r.Context(), id, uint64(user.ID))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrDonationReportNotFound), errors.Is(err, repository.ErrDonationReportNotPending):
+			handleDonationReportError(w, err, "failed to confirm donation report")
+		default:
+			handleLedgerWriteError(w, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": model.DonationReportStatusConfirmed, "entryId": entryID})
+}
+
+func (s *Server) handleRejectDonationReport(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid donation report id")
+	if !ok {
+		return
+	}
+	var req reviewRejectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	if err := s.donationReports.Reject(r.Context(), id, uint64(user.ID), req.Reason); err != nil {
+		handleDonationReportError(w, err, "failed to reject donation report")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": model.DonationReportStatusRejected})
+}
+
+func handleDonationReportError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrDonationReportNotFound):
+		writeErr(w, http.StatusNotFound, "donation report not found")
+	case errors.Is(err, repository.ErrDonationReportNotPending):
+		writeErr(w, http.StatusConflict, "donation report already reviewed")
+	default:
+		if isValidationErr(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func toDonationReportResponseItem(item model.DonationReport) donationReportResponseItem {
+	out := donationReportResponseItem{
+		ID:            item.ID,
+		ReporterID:    item.ReporterID,
+		Donor:         item.Donor,
+		Group:         item.GroupName,
+		Amount:        item.Amount,
+		DonatedAt:     item.DonatedAt.Format(time.RFC3339),
+		ScreenshotURL: item.ScreenshotURL,
+		Status:        string(item.Status),
+		ReviewedBy:    item.ReviewedBy,
+		ReviewNote:    item.ReviewNote,
+		EntryID:       item.EntryID,
+		CreatedAt:     item.CreatedAt.Format(time.RFC3339),
+	}
+	if item.ReviewedAt != nil {
+		reviewedAt := item.ReviewedAt.Format(time.RFC3339)
+		out.ReviewedAt = &reviewedAt
+	}
+	return out
+}
@@ -0,0 +1,208 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	inviteCodeLen      = 10
+	maxInviteCodeUses  = 10000
+	inviteCodeAttempts = 3
+)
+
+type inviteCodeCreateRequest struct {
+	Group   string `json:"group"`
+	Role    string `json:"role"`
+	MaxUses *int   `json:"maxUses"`
+	// ExpiresAt is an RFC 3339 timestamp; empty means the code never
+	// expires.
+	ExpiresAt string `json:"expiresAt"`
+}
+
+type inviteCodeResponseItem struct {
+	ID        int64   `json:"id"`
+	Code      string  `json:"code"`
+	Group     string  `json:"group"`
+	Role      string  `json:"role"`
+	MaxUses   *int    `json:"max_uses"`
+	UsedCount int     `json:"used_count"`
+	ExpiresAt *string `json:"expires_at"`
+	RevokedAt *string `json:"revoked_at,omitempty"`
+	Usable    bool    `json:"usable"`
+	CreatedBy int64   `json:"created_by"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// handleCreateInviteCode issues an invite code. Role defaults to member.
+func (s *Server) handleCreateInviteCode(w http.ResponseWriter, r *http.Request) {
+	var req inviteCodeCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	invite := inviteCode{
+		Group:     strings.TrimSpace(req.Group),
+		Role:      strings.TrimSpace(req.Role),
+		MaxUses:   req.MaxUses,
+		CreatedBy: authUserFromContext(r.Context()).ID,
+	}
+	if invite.Role == "" {
+		invite.Role = roleMember
+	}
+	if !isValidRole(invite.Role) {
+		writeErr(w, http.StatusBadRequest, "invalid role")
+		return
+	}
+	if len([]rune(invite.Group)) > maxGroupNameLen {
+		writeErr(w, http.StatusBadRequest, "invalid group")
+		return
+	}
+	if invite.MaxUses != nil && (*invite.MaxUses <= 0 || *invite.MaxUses > maxInviteCodeUses) {
+		writeErr(w, http.StatusBadRequest, "invalid maxUses")
+		return
+	}
+	if raw := strings.TrimSpace(req.ExpiresAt); raw != "" {
+		expiresAt, err := time.Parse(time.RFC3339, raw)
+		if err != nil || !expiresAt.After(time.Now()) {
+			writeErr(w, http.StatusBadRequest, "invalid expiresAt")
+			return
+		}
+		invite.ExpiresAt = &expiresAt
+	}
+
+	// Codes are short enough that a collision is possible, if unlikely;
+	// retry with a fresh one when the unique key rejects it.
+	var created inviteCode
+	var err error
+	for i := 0; i < inviteCodeAttempts; i++ {
+		invite.Code, err = randomCode(inviteCodeLen)
+		if err != nil {
+			break
+		}
+		created, err = createInviteCode(r.Context(), s.db, invite)
+		if err == nil || !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
+			break
+		}
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to create invite code")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, toInviteCodeResponseItem(created, time.Now()))
+}
+
+func (s *Server) handleListInviteCodes(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, ok := parsePageQuery(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := listInviteCodes(r.Context(), s.db, pageSize, (page-1)*pageSize)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list invite codes")
+		return
+	}
+	total, err := countInviteCodes(r.Context(), s.db)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list invite codes")
+		return
+	}
+
+	now := time.Now()
+	items := make([]inviteCodeResponseItem, 0, len(codes))
+	for _, code := range codes {
+		items = append(items, toInviteCodeResponseItem(code, now))
+	}
+	writePage(w, items, page, pageSize, total)
+}
+
+// handleRevokeInviteCode stops a code from registering anyone else. Accounts
+// that already used it are not affected.
+func (s *Server) handleRevokeInviteCode(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid invite code id")
+	if !ok {
+		return
+	}
+
+	if err := revokeInviteCode(r.Context(), s.db, int64(id), time.Now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErr(w, http.StatusNotFound, "invite code not found")
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, "failed to revoke invite code")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// registerUser creates an account for a new phone number. With an invite
+// code the account takes the code's role and is active at once. Without one
+// it is a member, pending approval when the server requires that; when the
+// server requires invites it fails with errInviteCodeRequired.
+func (s *Server) registerUser(ctx context.Context, phone, passwordHash, code string) (User, error) {
+	code = normalizeCode(code)
+	if code == "" {
+		if s.cfg.RegistrationInviteRequired {
+			return User{}, errInviteCodeRequired
+		}
+		status := userStatusActive
+		if s.cfg.RegistrationApprovalRequired {
+			status = userStatusPending
+		}
+		return insertUser(ctx, s.db, phone, passwordHash, roleMember, status, nil)
+	}
+	return createUserWithInvite(ctx, s.db, phone, passwordHash, code, time.Now())
+}
+
+// checkRegistration reports ahead of time whether registerUser would refuse
+// code, so callers can reject a registration before spending anything on
+// it. The code is checked again, under lock, when the account is created.
+func (s *Server) checkRegistration(ctx context.Context, code string, now time.Time) error {
+	code = normalizeCode(code)
+	if code == "" {
+		if s.cfg.RegistrationInviteRequired {
+			return errInviteCodeRequired
+		}
+		return nil
+	}
+	invite, err := findInviteCodeByCode(ctx, s.db, code)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !invite.usable(now)) {
+		return errInvalidInviteCode
+	}
+	return err
+}
+
+func isInviteError(err error) bool {
+	return errors.Is(err, errInviteCodeRequired) || errors.Is(err, errInvalidInviteCode)
+}
+
+func toInviteCodeResponseItem(code inviteCode, now time.Time) inviteCodeResponseItem {
+	out := inviteCodeResponseItem{
+		ID:        code.ID,
+		Code:      code.Code,
+		Group:     code.Group,
+		Role:      code.Role,
+		MaxUses:   code.MaxUses,
+		UsedCount: code.UsedCount,
+		Usable:    code.usable(now),
+		CreatedBy: code.CreatedBy,
+		CreatedAt: code.CreatedAt.Format(time.RFC3339),
+	}
+	if code.ExpiresAt != nil {
+		expiresAt := code.ExpiresAt.Format(time.RFC3339)
+		out.ExpiresAt = &expiresAt
+	}
+	if code.RevokedAt != nil {
+		revokedAt := code.RevokedAt.Format(time.RFC3339)
+		out.RevokedAt = &revokedAt
+	}
+	return out
+}
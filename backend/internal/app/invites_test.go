@@ -0,0 +1,38 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInviteCodeUsable(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+	two := 2
+
+	cases := []struct {
+		name string
+		code inviteCode
+		want bool
+	}{
+		{"unlimited", inviteCode{}, true},
+		{"uses left", inviteCode{MaxUses: &two, UsedCount: 1}, true},
+		{"used up", inviteCode{MaxUses: &two, UsedCount: 2}, false},
+		{"not expired", inviteCode{ExpiresAt: &later}, true},
+		{"expired", inviteCode{ExpiresAt: &earlier}, false},
+		{"expires now", inviteCode{ExpiresAt: &now}, false},
+		{"revoked", inviteCode{RevokedAt: &earlier}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.code.usable(now); got != tc.want {
+			t.Errorf("%s: usable = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	if got := normalizeCode(" abcd-efgh 23 "); got != "ABCDEFGH23" {
+		t.Errorf("normalizeCode = %q", got)
+	}
+}
@@ -1,17 +1,132 @@
 package app
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// defaultJWTKeyID names the key built from JWT_SECRET when JWT_KEYS is not
+// set. Tokens signed before keys had ids carry no kid and are checked
+// against the key with this id.
+const defaultJWTKeyID = "default"
+
+const (
+	jwtAlgHS256 = "HS256"
+	jwtAlgEdDSA = "EdDSA"
+)
+
+// JWTKey is one signing or verification key. HS256 keys hold a shared
+// secret; EdDSA keys hold an Ed25519 private key, whose public half is
+// published in the JWKS.
+type JWTKey struct {
+	ID        string
+	Algorithm string
+	secret    []byte
+	private   ed25519.PrivateKey
+	// verifyOnly marks the key kept from JWT_SECRET next to JWT_KEYS; it
+	// checks old tokens but never signs.
+	verifyOnly bool
+}
+
+// ParseJWTKeys reads JWT_KEYS, a semicolon-separated list of
+// kid:algorithm:material entries. HS256 material is the secret itself;
+// EdDSA material is a base64 Ed25519 seed (32 bytes) or private key (64
+// bytes). An empty spec yields a single HS256 key from fallbackSecret. When
+// the spec has no "default" entry, fallbackSecret is kept as a verify-only
+// "default" key, so moving from JWT_SECRET to JWT_KEYS does not sign everyone
+// out; the development secret is never kept that way.
+func ParseJWTKeys(spec, fallbackSecret string) ([]JWTKey, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return []JWTKey{{ID: defaultJWTKeyID, Algorithm: jwtAlgHS256, secret: []byte(fallbackSecret)}}, nil
+	}
+
+	keys := make([]JWTKey, 0)
+	seen := make(map[string]bool)
+	for i, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			// The entry may be a bare secret, so only its position is reported.
+			return nil, fmt.Errorf("key entry %d is not kid:algorithm:material", i+1)
+		}
+		id, material := parts[0], parts[2]
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate key id %q", id)
+		}
+		seen[id] = true
+
+		switch parts[1] {
+		case jwtAlgHS256:
+			keys = append(keys, JWTKey{ID: id, Algorithm: jwtAlgHS256, secret: []byte(material)})
+		case jwtAlgEdDSA:
+			raw, err := base64.StdEncoding.DecodeString(material)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: invalid base64", id)
+			}
+			var private ed25519.PrivateKey
+			switch len(raw) {
+			case ed25519.SeedSize:
+				private = ed25519.NewKeyFromSeed(raw)
+			case ed25519.PrivateKeySize:
+				private = ed25519.PrivateKey(raw)
+			default:
+				return nil, fmt.Errorf("key %q: Ed25519 key must be %d or %d bytes", id, ed25519.SeedSize, ed25519.PrivateKeySize)
+			}
+			keys = append(keys, JWTKey{ID: id, Algorithm: jwtAlgEdDSA, private: private})
+		default:
+			return nil, fmt.Errorf("key %q: unsupported algorithm %q", id, parts[1])
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no keys")
+	}
+	if !seen[defaultJWTKeyID] && fallbackSecret != "" && fallbackSecret != devJWTSecret {
+		keys = append(keys, JWTKey{ID: defaultJWTKeyID, Algorithm: jwtAlgHS256, secret: []byte(fallbackSecret), verifyOnly: true})
+	}
+	return keys, nil
+}
+
+func (k JWTKey) signingMethod() jwt.SigningMethod {
+	if k.Algorithm == jwtAlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodHS256
+}
+
+func (k JWTKey) signingKey() interface{} {
+	if k.Algorithm == jwtAlgEdDSA {
+		return k.private
+	}
+	return k.secret
+}
+
+func (k JWTKey) verificationKey() interface{} {
+	if k.Algorithm == jwtAlgEdDSA {
+		return k.private.Public()
+	}
+	return k.secret
+}
+
+// TokenManager signs with one active key and accepts tokens from every
+// configured key, so a new key can take over signing while tokens issued
+// under the previous one stay valid until they expire.
 type TokenManager struct {
-	secret          []byte
+	signer          JWTKey
+	keys            map[string]JWTKey
 	accessTokenTTL  time.Duration
 	refreshTokenTTL time.Duration
 }
@@ -20,6 +135,12 @@ type UserClaims struct {
 	UserID int64
 	Phone  string
 	Role   string
+	// SessionID names the login both tokens belong to (the refresh token
+	// family); it is carried as the "sid" claim.
+	SessionID string
+	// TokenVersion is the user's token version at issue time, the "ver"
+	// claim.
+	TokenVersion int64
 }
 
 type TokenPair struct {
@@ -27,12 +148,30 @@ type TokenPair struct {
 	RefreshToken string `json:"refreshToken"`
 }
 
-func NewTokenManager(secret string, accessTTL, refreshTTL time.Duration) *TokenManager {
-	return &TokenManager{
-		secret:          []byte(secret),
+// NewTokenManager signs with the key named signingKeyID, or with the only key
+// that can sign when signingKeyID is empty.
+func NewTokenManager(keys []JWTKey, signingKeyID string, accessTTL, refreshTTL time.Duration) (*TokenManager, error) {
+	m := &TokenManager{
+		keys:            make(map[string]JWTKey, len(keys)),
 		accessTokenTTL:  accessTTL,
 		refreshTokenTTL: refreshTTL,
 	}
+	signers := make([]string, 0, len(keys))
+	for _, key := range keys {
+		m.keys[key.ID] = key
+		if !key.verifyOnly {
+			signers = append(signers, key.ID)
+		}
+	}
+	if signingKeyID == "" && len(signers) == 1 {
+		signingKeyID = signers[0]
+	}
+	signer, ok := m.keys[signingKeyID]
+	if !ok || signer.verifyOnly {
+		return nil, fmt.Errorf("signing key %q is not configured", signingKeyID)
+	}
+	m.signer = signer
+	return m, nil
 }
 
 func (m *TokenManager) GenerateTokenPair(claims UserClaims) (TokenPair, string, time.Time, error) {
@@ -57,10 +196,18 @@ func (m *TokenManager) GenerateTokenPair(claims UserClaims) (TokenPair, string,
 
 func (m *TokenManager) ParseToken(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			kid = defaultJWTKeyID
+		}
+		key, ok := m.keys[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		if token.Method.Alg() != key.signingMethod().Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return m.secret, nil
+		return key.verificationKey(), nil
 	})
 	if err != nil {
 		return nil, err
@@ -83,6 +230,7 @@ func (m *TokenManager) generateToken(claims UserClaims, tokenType, jti string, e
 		"sub":   strconv.FormatInt(claims.UserID, 10),
 		"phone": claims.Phone,
 		"role":  claims.Role,
+		"ver":   claims.TokenVersion,
 		"type":  tokenType,
 		"exp":   expiresAt.Unix(),
 		"iat":   time.Now().Unix(),
@@ -90,8 +238,54 @@ func (m *TokenManager) generateToken(claims UserClaims, tokenType, jti string, e
 	if jti != "" {
 		mapClaims["jti"] = jti
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims)
-	return token.SignedString(m.secret)
+	if claims.SessionID != "" {
+		mapClaims["sid"] = claims.SessionID
+	}
+	token := jwt.NewWithClaims(m.signer.signingMethod(), mapClaims)
+	token.Header["kid"] = m.signer.ID
+	return token.SignedString(m.signer.signingKey())
+}
+
+// JWK is the JSON Web Key form of a public verification key.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	X         string `json:"x"`
+}
+
+// PublicKeys lists the asymmetric keys as JWKs, signer first. Shared HS256
+// secrets are never published, so tokens they sign can only be checked by
+// this server.
+func (m *TokenManager) PublicKeys() []JWK {
+	keys := make([]JWK, 0, len(m.keys))
+	add := func(key JWTKey) {
+		if key.Algorithm != jwtAlgEdDSA {
+			return
+		}
+		keys = append(keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			KeyID:     key.ID,
+			Algorithm: jwtAlgEdDSA,
+			Use:       "sig",
+			X:         base64.RawURLEncoding.EncodeToString(key.private.Public().(ed25519.PublicKey)),
+		})
+	}
+	add(m.signer)
+	ids := make([]string, 0, len(m.keys))
+	for id := range m.keys {
+		if id != m.signer.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		add(m.keys[id])
+	}
+	return keys
 }
 
 func randomHex(byteLen int) (string, error) {
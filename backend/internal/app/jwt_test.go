@@ -0,0 +1,143 @@
+package app
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func mustTokenManager(t *testing.T, spec, secret, signer string) *TokenManager {
+	t.Helper()
+	keys, err := ParseJWTKeys(spec, secret)
+	if err != nil {
+		t.Fatalf("parse keys: %v", err)
+	}
+	m, err := NewTokenManager(keys, signer, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("new token manager: %v", err)
+	}
+	return m
+}
+
+func TestTokenManagerKeyRotation(t *testing.T) {
+	seed := base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize))
+	old := mustTokenManager(t, "", "old-secret", "")
+	rotated := mustTokenManager(t, "default:HS256:old-secret;2024b:EdDSA:"+seed, "", "2024b")
+
+	oldPair, _, _, err := old.GenerateTokenPair(UserClaims{UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPair, _, _, err := rotated.GenerateTokenPair(UserClaims{UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rotated.ParseToken(oldPair.AccessToken); err != nil {
+		t.Errorf("token from the previous key rejected: %v", err)
+	}
+	if _, err := rotated.ParseToken(newPair.AccessToken); err != nil {
+		t.Errorf("token from the signing key rejected: %v", err)
+	}
+	if _, err := old.ParseToken(newPair.AccessToken); err == nil {
+		t.Error("token with an unknown kid accepted")
+	}
+
+	token, _, err := jwt.NewParser().ParseUnverified(newPair.AccessToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Header["kid"] != "2024b" || token.Method.Alg() != "EdDSA" {
+		t.Errorf("header = %v, want kid 2024b signed with EdDSA", token.Header)
+	}
+}
+
+func TestTokenManagerRejectsAlgorithmMismatch(t *testing.T) {
+	m := mustTokenManager(t, "", "secret", "")
+	// An EdDSA token claiming the HS256 key's kid must not verify.
+	_, private, _ := ed25519.GenerateKey(nil)
+	forged := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Minute).Unix()})
+	forged.Header["kid"] = defaultJWTKeyID
+	signed, err := forged.SignedString(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ParseToken(signed); err == nil {
+		t.Error("token signed with the wrong algorithm accepted")
+	}
+}
+
+func TestPublicKeysOnlyPublishesEd25519(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	seed[0] = 1
+	m := mustTokenManager(t, "hs:HS256:secret;ed:EdDSA:"+base64.StdEncoding.EncodeToString(seed), "", "hs")
+
+	keys := m.PublicKeys()
+	if len(keys) != 1 || keys[0].KeyID != "ed" || keys[0].KeyType != "OKP" {
+		t.Fatalf("keys = %+v, want only the Ed25519 key", keys)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(keys[0].X)
+	if err != nil || !ed25519.PublicKey(x).Equal(ed25519.NewKeyFromSeed(seed).Public()) {
+		t.Errorf("x = %q does not match the public key", keys[0].X)
+	}
+}
+
+func TestParseJWTKeysErrors(t *testing.T) {
+	cases := map[string]string{
+		"a:HS256":             "kid:algorithm:material",
+		"a:HS256:x;a:HS256:y": "duplicate",
+		"a:RS256:x":           "unsupported algorithm",
+		"a:EdDSA:not-base64!": "invalid base64",
+		"a:EdDSA:AAAA":        "Ed25519 key must be",
+		" ; ":                 "no keys",
+	}
+	for spec, want := range cases {
+		if _, err := ParseJWTKeys(spec, ""); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseJWTKeys(%q) = %v, want error containing %q", spec, err, want)
+		}
+	}
+	if _, err := NewTokenManager([]JWTKey{{ID: "a", Algorithm: jwtAlgHS256}}, "b", time.Minute, time.Hour); err == nil {
+		t.Error("expected an error for an unknown signing key")
+	}
+	if _, err := ParseJWTKeys("a:HS256:x;top-secret", ""); err == nil || strings.Contains(err.Error(), "top-secret") {
+		t.Errorf("error %v must not quote the entry", err)
+	}
+}
+
+func TestParseJWTKeysKeepsSecret(t *testing.T) {
+	old := mustTokenManager(t, "", "old-secret", "")
+	pair, _, _, err := old.GenerateTokenPair(UserClaims{UserID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JWT_SECRET keeps verifying and the only JWT_KEYS entry still signs.
+	moved := mustTokenManager(t, "2024a:HS256:new-secret", "old-secret", "")
+	if _, err := moved.ParseToken(pair.AccessToken); err != nil {
+		t.Errorf("token signed with JWT_SECRET rejected: %v", err)
+	}
+	if moved.signer.ID != "2024a" {
+		t.Errorf("signer = %q, want 2024a", moved.signer.ID)
+	}
+	keys, _ := ParseJWTKeys("2024a:HS256:new-secret", "old-secret")
+	if _, err := NewTokenManager(keys, defaultJWTKeyID, time.Minute, time.Hour); err == nil {
+		t.Error("expected the kept secret not to sign")
+	}
+
+	// An explicit default entry and the development secret are not kept.
+	for _, tc := range []struct{ spec, secret string }{
+		{"default:HS256:other;2024a:HS256:new-secret", "old-secret"},
+		{"2024a:HS256:new-secret", devJWTSecret},
+	} {
+		keys, _ := ParseJWTKeys(tc.spec, tc.secret)
+		for _, key := range keys {
+			if key.verifyOnly {
+				t.Errorf("ParseJWTKeys(%q, %q) kept JWT_SECRET", tc.spec, tc.secret)
+			}
+		}
+	}
+}
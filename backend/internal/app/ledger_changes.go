@@ -0,0 +1,200 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+	"propets/backend/internal/service"
+)
+
+type ledgerChangeResponseItem struct {
+	ID          uint64          `json:"id"`
+	Action      string          `json:"action"`
+	EntryID     *uint64         `json:"entry_id"`
+	EntryType   string          `json:"entry_type"`
+	Amount      model.Money     `json:"amount"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	RequestedBy uint64          `json:"requested_by"`
+	ReviewedBy  *uint64         `json:"reviewed_by"`
+	ReviewedAt  *string         `json:"reviewed_at"`
+	ReviewNote  string          `json:"review_note"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// writePendingChange answers a ledger write that is waiting for a second
+// admin with 202 and the id of the queued change.
+func writePendingChange(w http.ResponseWriter, result service.LedgerWriteResult) {
+	resp := map[string]interface{}{"changeId": result.ChangeID, "status": model.LedgerChangeStatusPending}
+	if result.EntryID != 0 {
+		resp["entryId"] = result.EntryID
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+func (s *Server) handleListLedgerChanges(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePositiveQueryInt(r, "page")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	pageSize, err := parsePositiveQueryInt(r, "pageSize")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := s.approvals.List(r.Context(), service.ListLedgerChangesInput{
+		Status:   r.URL.Query().Get("status"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidLedgerChangeStatus):
+			writeErr(w, http.StatusBadRequest, "invalid status")
+		case errors.Is(err, service.ErrInvalidPage):
+			writeErr(w, http.StatusBadRequest, "invalid page")
+		case errors.Is(err, service.ErrInvalidPageSize):
+			writeErr(w, http.StatusBadRequest, "invalid pageSize")
+		default:
+			writeErr(w, http.StatusInternalServerError, "failed to list ledger changes")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"items":       toLedgerChangeResponseItems(result.Items),
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total":       result.Total,
+		"total_pages": result.TotalPages,
+	})
+}
+
+func (s *Server) handleGetLedgerChange(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid ledger change id")
+	if !ok {
+		return
+	}
+
+	item, err := s.approvals.Get(r.Context(), id)
+	if err != nil {
+		handleLedgerChangeError(w, err, "failed to fetch ledger change")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toLedgerChangeResponseItem(item))
+}
+
+func (s *Server) handleApproveLedgerChange(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid ledger change id")
+	if !ok {
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	entryID, err := s.approvals.Approve(r.Context(), id, uint64(user.ID))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrLedgerChangeNotFound),
+			errors.Is(err, repository.ErrLedgerChangeNotPending),
+			errors.Is(err, service.ErrSameApprover):
+			handleLedgerChangeError(w, err, "failed to approve ledger change")
+		default:
+			handleLedgerWriteError(w, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": model.LedgerChangeStatusApproved, "entryId": entryID})
+}
+
+func (s *Server) handleRejectLedgerChange(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid ledger change id")
+	if !ok {
+		return
+	}
+	var req reviewRejectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	if err := s.approvals.Reject(r.Context(), id, uint64(user.ID), req.Reason); err != nil {
+		handleLedgerChangeError(w, err, "failed to reject ledger change")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": model.LedgerChangeStatusRejected})
+}
+
+func (s *Server) handleLedgerEntryHistory(w http.ResponseWriter, r *http.Request) {
+	entryID, ok := parsePathID(w, r, "invalid entry id")
+	if !ok {
+		return
+	}
+
+	items, err := s.approvals.EntryHistory(r.Context(), entryID)
+	if err != nil {
+		if errors.Is(err, repository.ErrLedgerEntryNotFound) {
+			writeErr(w, http.StatusNotFound, "entry not found")
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, "failed to fetch entry history")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"entry_id": entryID, "items": toLedgerChangeResponseItems(items)})
+}
+
+func handleLedgerChangeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrLedgerChangeNotFound):
+		writeErr(w, http.StatusNotFound, "ledger change not found")
+	case errors.Is(err, repository.ErrLedgerChangeNotPending):
+		writeErr(w, http.StatusConflict, "ledger change already reviewed")
+	case errors.Is(err, service.ErrSameApprover):
+		writeErr(w, http.StatusForbidden, err.Error())
+	default:
+		if isValidationErr(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func toLedgerChangeResponseItems(items []model.LedgerChange) []ledgerChangeResponseItem {
+	out := make([]ledgerChangeResponseItem, 0, len(items))
+	for _, item := range items {
+		out = append(out, toLedgerChangeResponseItem(item))
+	}
+	return out
+}
+
+func toLedgerChangeResponseItem(item model.LedgerChange) ledgerChangeResponseItem {
+	out := ledgerChangeResponseItem{
+		ID:          item.ID,
+		Action:      string(item.Action),
+		EntryID:     item.EntryID,
+		EntryType:   string(item.EntryType),
+		Amount:      item.Amount,
+		Payload:     item.Payload,
+		Status:      string(item.Status),
+		RequestedBy: item.RequestedBy,
+		ReviewedBy:  item.ReviewedBy,
+		ReviewNote:  item.ReviewNote,
+		CreatedAt:   item.CreatedAt.Format(time.RFC3339),
+	}
+	if item.ReviewedAt != nil {
+		reviewedAt := item.ReviewedAt.Format(time.RFC3339)
+		out.ReviewedAt = &reviewedAt
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// maintenanceStep purges one kind of row and reports how many it removed.
+type maintenanceStep struct {
+	what  string
+	purge func() (int64, error)
+}
+
+// RunMaintenance purges what the tables would otherwise keep forever: refresh
+// tokens and one-time codes past RefreshTokenRetention, idempotency keys
+// older than IdempotencyKeyTTL, login counters that went quiet and security
+// events past SecurityEventRetention. It logs what it removed.
+func (s *Server) RunMaintenance(ctx context.Context) error {
+	started := time.Now()
+	cutoff := started.Add(-s.cfg.RefreshTokenRetention)
+	quietSince := started.Add(-max(s.cfg.LoginLockout, loginLockoutMemory))
+
+	steps := []maintenanceStep{
+		{"refresh tokens", func() (int64, error) { return purgeRefreshTokens(ctx, s.db, cutoff) }},
+		{"one-time codes", func() (int64, error) { return purgeOneTimeCodes(ctx, s.db, cutoff) }},
+		{"idempotency keys", func() (int64, error) { return purgeIdempotencyKeys(ctx, s.db, s.cfg.IdempotencyKeyTTL) }},
+		{"login counters", func() (int64, error) { return purgeLoginAttempts(ctx, s.db, quietSince, started) }},
+	}
+	if s.cfg.SecurityEventRetention > 0 {
+		steps = append(steps, maintenanceStep{"security events", func() (int64, error) {
+			return purgeSecurityEvents(ctx, s.db, started.Add(-s.cfg.SecurityEventRetention))
+		}})
+	}
+
+	purged := make([]string, 0, len(steps))
+	for _, step := range steps {
+		n, err := step.purge()
+		purged = append(purged, fmt.Sprintf("%d %s", n, step.what))
+		if err != nil {
+			log.Printf("maintenance: purged %s before failing", strings.Join(purged, ", "))
+			return err
+		}
+	}
+
+	log.Printf("maintenance: purged %s in %s", strings.Join(purged, ", "), time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+func (s *Server) runMaintenanceScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunMaintenance(ctx); err != nil {
+			log.Printf("maintenance: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package app
+
+import "net/http"
+
+// permission names one capability a route can require. Roles are fixed sets
+// of permissions; the role string stays what tokens and the users table
+// carry, so the original admin and member accounts keep working unchanged.
+type permission string
+
+const (
+	// permLedgerSubmit covers what members hand in for review: reimbursement
+	// requests, donation reports and questions on entries.
+	permLedgerSubmit permission = "ledger.submit"
+	// permLedgerWrite records and edits entries, budgets and recurring
+	// templates, and reviews submissions.
+	permLedgerWrite permission = "ledger.write"
+	// permLedgerApprove approves or rejects changes queued for a second admin.
+	permLedgerApprove permission = "ledger.approve"
+	permLedgerDelete  permission = "ledger.delete"
+	permUsersManage   permission = "users.manage"
+	// permAuditRead shows deleted entries, the change queue and entry
+	// histories, hidden comments and everyone's submissions.
+	permAuditRead permission = "audit.read"
+)
+
+const (
+	roleAdmin     = "admin"
+	roleTreasurer = "treasurer"
+	roleAuditor   = "auditor"
+	roleMember    = "member"
+	roleViewer    = "viewer"
+)
+
+// rolePermissions lists what each role may do beyond reading the ledger,
+// which every signed-in role can.
+var rolePermissions = map[string][]permission{
+	roleAdmin:     {permLedgerSubmit, permLedgerWrite, permLedgerApprove, permLedgerDelete, permUsersManage, permAuditRead},
+	roleTreasurer: {permLedgerSubmit, permLedgerWrite, permAuditRead},
+	roleAuditor:   {permAuditRead},
+	roleMember:    {permLedgerSubmit},
+	roleViewer:    {},
+}
+
+func isValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func roleHasPermission(role string, perm permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == perm {
+			return true
+		}
+	}
+	return false
+}
+
+func (u authContextUser) can(perm permission) bool {
+	return roleHasPermission(u.Role, perm)
+}
+
+func (s *Server) withPermission(perm permission, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authUserFromContext(r.Context()).can(perm) {
+			writeErr(w, http.StatusForbidden, "forbidden")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
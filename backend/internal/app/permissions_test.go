@@ -0,0 +1,27 @@
+package app
+
+import "testing"
+
+func TestRolePermissions(t *testing.T) {
+	all := []permission{permLedgerSubmit, permLedgerWrite, permLedgerApprove, permLedgerDelete, permUsersManage, permAuditRead}
+	want := map[string][]permission{
+		roleAdmin:     all,
+		roleTreasurer: {permLedgerSubmit, permLedgerWrite, permAuditRead},
+		roleAuditor:   {permAuditRead},
+		roleMember:    {permLedgerSubmit},
+		roleViewer:    nil,
+		"":            nil,
+		"superuser":   nil,
+	}
+	for role, granted := range want {
+		for _, perm := range all {
+			expected := false
+			for _, g := range granted {
+				expected = expected || g == perm
+			}
+			if got := roleHasPermission(role, perm); got != expected {
+				t.Errorf("role %q permission %s: got %v, want %v", role, perm, got, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	maxDisplayNameLen = 32
+	maxGroupNameLen   = 64
+	maxUserGroups     = 10
+	maxAvatarURLLen   = 512
+)
+
+// profileRequest is the profile part of PATCH /api/me and
+// PATCH /api/users/{id}. Absent fields are left unchanged; groups replaces
+// the whole list.
+type profileRequest struct {
+	DisplayName    *string   `json:"displayName"`
+	WechatNickname *string   `json:"wechatNickname"`
+	AvatarURL      *string   `json:"avatarUrl"`
+	Groups         *[]string `json:"groups"`
+}
+
+// handleGetMe returns the caller's account and profile.
+func (s *Server) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	user, err := findUserProfile(r.Context(), s.db, authUserFromContext(r.Context()).ID)
+	if err != nil {
+		handleUserError(w, err, "failed to get profile")
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserResponseItem(user))
+}
+
+// handleUpdateMe edits the caller's own profile. Role and status are only
+// changed by admins through PATCH /api/users/{id}.
+func (s *Server) handleUpdateMe(w http.ResponseWriter, r *http.Request) {
+	var req profileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	update, err := req.parse()
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if update.empty() {
+		writeErr(w, http.StatusBadRequest, "no profile fields to update")
+		return
+	}
+
+	id := authUserFromContext(r.Context()).ID
+	if err := updateUserProfile(r.Context(), s.db, id, update); err != nil {
+		handleUserError(w, err, "failed to update profile")
+		return
+	}
+	user, err := findUserProfile(r.Context(), s.db, id)
+	if err != nil {
+		handleUserError(w, err, "failed to get profile")
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserResponseItem(user))
+}
+
+// parse trims and checks the requested profile fields.
+func (req profileRequest) parse() (profileUpdate, error) {
+	var update profileUpdate
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if len([]rune(name)) > maxDisplayNameLen {
+			return profileUpdate{}, errors.New("invalid displayName")
+		}
+		update.DisplayName = &name
+	}
+	if req.WechatNickname != nil {
+		nickname := strings.TrimSpace(*req.WechatNickname)
+		if len([]rune(nickname)) > maxDisplayNameLen {
+			return profileUpdate{}, errors.New("invalid wechatNickname")
+		}
+		update.WechatNickname = &nickname
+	}
+	if req.AvatarURL != nil {
+		avatar := strings.TrimSpace(*req.AvatarURL)
+		if avatar != "" && !isValidAvatarURL(avatar) {
+			return profileUpdate{}, errors.New("invalid avatarUrl")
+		}
+		update.AvatarURL = &avatar
+	}
+	if req.Groups != nil {
+		groups, err := normalizeGroups(*req.Groups)
+		if err != nil {
+			return profileUpdate{}, err
+		}
+		update.Groups = &groups
+	}
+	return update, nil
+}
+
+// normalizeGroups trims group names and drops blanks and repeats, keeping
+// the order they were given in.
+func normalizeGroups(raw []string) ([]string, error) {
+	groups := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, name := range raw {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if len([]rune(name)) > maxGroupNameLen {
+			return nil, errors.New("invalid groups")
+		}
+		seen[name] = true
+		groups = append(groups, name)
+	}
+	if len(groups) > maxUserGroups {
+		return nil, errors.New("too many groups")
+	}
+	return groups, nil
+}
+
+// isValidAvatarURL accepts absolute http(s) links; avatars are hosted
+// elsewhere, typically the WeChat CDN.
+func isValidAvatarURL(raw string) bool {
+	if len(raw) > maxAvatarURLLen {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProfileRequestParse(t *testing.T) {
+	name := "  小雪 "
+	groups := []string{" 一群", "二群", "一群", ""}
+	update, err := profileRequest{DisplayName: &name, Groups: &groups}.parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update.DisplayName != "小雪" {
+		t.Errorf("displayName = %q", *update.DisplayName)
+	}
+	if want := []string{"一群", "二群"}; !reflect.DeepEqual(*update.Groups, want) {
+		t.Errorf("groups = %q, want %q", *update.Groups, want)
+	}
+	if update.WechatNickname != nil || update.AvatarURL != nil {
+		t.Error("absent fields must stay nil")
+	}
+
+	long := strings.Repeat("猫", maxDisplayNameLen+1)
+	bad := []profileRequest{
+		{DisplayName: &long},
+		{AvatarURL: ptr("javascript:alert(1)")},
+		{AvatarURL: ptr("/avatars/1.png")},
+		{Groups: &[]string{strings.Repeat("群", maxGroupNameLen+1)}},
+	}
+	for _, req := range bad {
+		if _, err := req.parse(); err == nil {
+			t.Errorf("expected an error for %+v", req)
+		}
+	}
+
+	clear := ""
+	update, err = profileRequest{AvatarURL: &clear}.parse()
+	if err != nil || update.AvatarURL == nil || *update.AvatarURL != "" {
+		t.Errorf("clearing the avatar: %v, %v", update.AvatarURL, err)
+	}
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"propets/backend/internal/model"
+)
+
+type publicLedgerEntryItem struct {
+	ID          uint64                        `json:"id"`
+	EntryType   string                        `json:"entry_type"`
+	Amount      model.Money                   `json:"amount"`
+	OccurredAt  string                        `json:"occurred_at"`
+	Description string                        `json:"description"`
+	Category    string                        `json:"category"`
+	MonthKey    string                        `json:"month_key"`
+	Lines       []ledgerEntryLineResponseItem `json:"lines"`
+	Tags        []string                      `json:"tags"`
+}
+
+// registerPublicRoutes exposes the read-only transparency endpoints that work
+// without login. They share one per-client rate limit; a non-positive limit
+// turns it off.
+func (s *Server) registerPublicRoutes() {
+	limiter := newRateLimiter(s.cfg.PublicRateLimitPerMin, time.Minute)
+	public := func(h http.HandlerFunc) http.Handler {
+		if s.cfg.PublicRateLimitPerMin <= 0 {
+			return h
+		}
+		return s.withRateLimit(limiter, h)
+	}
+
+	s.mux.Handle("GET /api/public/summary", public(s.handleSummary))
+	s.mux.Handle("GET /api/public/summary/monthly", public(s.handleMonthlyStatistics))
+	s.mux.Handle("GET /api/public/ledger/entries", public(s.handlePublicLedgerEntries))
+}
+
+// handlePublicLedgerEntries lists entries without recorder ids and with donor
+// names treated according to the configured privacy policy.
+func (s *Server) handlePublicLedgerEntries(w http.ResponseWriter, r *http.Request) {
+	result, ok := s.queryLedgerEntries(w, r, false)
+	if !ok {
+		return
+	}
+
+	items := make([]publicLedgerEntryItem, 0, len(result.Items))
+	for _, entry := range result.Items {
+		items = append(items, publicLedgerEntryItem{
+			ID:          entry.ID,
+			EntryType:   string(entry.EntryType),
+			Amount:      entry.Amount,
+			OccurredAt:  entry.OccurredAt.Format(time.RFC3339),
+			Description: s.donorPrivacy.PublicDescription(entry),
+			Category:    string(entry.Category),
+			MonthKey:    entry.MonthKey,
+			Lines:       toLedgerEntryLineResponseItems(entry),
+			Tags:        entryTags(entry),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"items":       items,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total":       result.Total,
+		"total_pages": result.TotalPages,
+	})
+}
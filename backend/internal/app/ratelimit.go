@@ -0,0 +1,116 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRateLimitKeys bounds the limiter memory; expired windows are swept once
+// the map grows past it.
+const maxRateLimitKeys = 10000
+
+// rateLimiter is a fixed-window request counter keyed by client. It lives in
+// process memory, which is enough for the single backend instance we run.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, buckets: make(map[string]*rateBucket)}
+}
+
+// allow counts one request for key and reports whether it is within the
+// limit; when it is not, it also returns how long until the window resets.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok || now.Sub(bucket.start) >= l.window {
+		if !ok && len(l.buckets) >= maxRateLimitKeys {
+			l.sweep(now)
+		}
+		bucket = &rateBucket{start: now}
+		l.buckets[key] = bucket
+	}
+	if bucket.count >= l.limit {
+		return false, bucket.start.Add(l.window).Sub(now)
+	}
+	bucket.count++
+	return true, 0
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.start) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (s *Server) withRateLimit(limiter *rateLimiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, retryAfter := limiter.allow(s.clientIP(r), time.Now())
+		if !ok {
+			writeTooManyRequests(w, retryAfter)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	setRetryAfter(w, retryAfter)
+	writeErr(w, http.StatusTooManyRequests, "too many requests")
+}
+
+// setRetryAfter sets Retry-After in whole seconds, rounded up.
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// clientIP identifies the caller. Behind the frontend nginx every request
+// comes from the proxy, so X-Real-IP is used when the request comes from a
+// trusted proxy and the header holds a valid address. Anyone else could set
+// the header to anything, so their own address is used.
+func (s *Server) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if s.fromTrustedProxy(host) {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+			return ip.String()
+		}
+	}
+	return host
+}
+
+func (s *Server) fromTrustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	cases := []struct {
+		name    string
+		trusted string
+		remote  string
+		header  string
+		want    string
+	}{
+		{"no trusted proxy", "", "192.0.2.1:54321", "203.0.113.7", "192.0.2.1"},
+		{"trusted proxy", "192.0.2.0/24", "192.0.2.1:54321", "203.0.113.7", "203.0.113.7"},
+		{"trusted proxy by address", "192.0.2.1", "192.0.2.1:54321", "203.0.113.7", "203.0.113.7"},
+		{"trusted proxy over IPv6", "2001:db8::/32", "[2001:db8::5]:443", " 2001:db8::1 ", "2001:db8::1"},
+		{"spoofed header from a direct client", "192.0.2.1", "198.51.100.9:54321", "203.0.113.7", "198.51.100.9"},
+		{"trusted proxy without header", "192.0.2.0/24", "192.0.2.1:54321", "", "192.0.2.1"},
+		{"trusted proxy with an invalid header", "192.0.2.0/24", "192.0.2.1:54321", "not-an-ip", "192.0.2.1"},
+		{"trusted proxy with an oversized header", "192.0.2.0/24", "192.0.2.1:54321", strings.Repeat("1", 100), "192.0.2.1"},
+	}
+	for _, tc := range cases {
+		proxies, err := Config{TrustedProxies: tc.trusted}.TrustedProxyNets()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		s := &Server{trustedProxies: proxies}
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tc.remote
+		if tc.header != "" {
+			r.Header.Set("X-Real-IP", tc.header)
+		}
+		if got := s.clientIP(r); got != tc.want {
+			t.Errorf("%s: clientIP = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTrustedProxyNetsRejectsGarbage(t *testing.T) {
+	for _, spec := range []string{"nginx", "192.0.2.0/33", "192.0.2.1,,bogus"} {
+		if _, err := (Config{TrustedProxies: spec}).TrustedProxyNets(); err == nil {
+			t.Errorf("TrustedProxyNets(%q) accepted", spec)
+		}
+	}
+}
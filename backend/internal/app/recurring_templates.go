@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+	"propets/backend/internal/service"
+)
+
+type recurringTemplateCreateRequest struct {
+	EntryType  string `json:"entryType"`
+	Amount     string `json:"amount"`
+	Donor      string `json:"donor"`
+	Purpose    string `json:"purpose"`
+	HandledBy  string `json:"handledBy"`
+	Category   string `json:"category"`
+	Frequency  string `json:"frequency"`
+	DayOfMonth int    `json:"dayOfMonth"`
+	StartDate  string `json:"startDate"`
+	EndDate    string `json:"endDate"`
+}
+
+type recurringTemplateUpdateRequest struct {
+	Active  *bool   `json:"active"`
+	EndDate *string `json:"endDate"`
+}
+
+type recurringTemplateResponseItem struct {
+	ID         uint64      `json:"id"`
+	EntryType  string      `json:"entry_type"`
+	Amount     model.Money `json:"amount"`
+	Donor      string      `json:"donor"`
+	Purpose    string      `json:"purpose"`
+	HandledBy  string      `json:"handled_by"`
+	Category   string      `json:"category"`
+	Frequency  string      `json:"frequency"`
+	DayOfMonth int         `json:"day_of_month"`
+	StartDate  string      `json:"start_date"`
+	EndDate    *string     `json:"end_date"`
+	Active     bool        `json:"active"`
+	LastPeriod string      `json:"last_period"`
+	CreatedBy  uint64      `json:"created_by"`
+	CreatedAt  string      `json:"created_at"`
+}
+
+func (s *Server) handleListRecurringTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.recurring.List(r.Context())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list recurring templates")
+		return
+	}
+
+	items := make([]recurringTemplateResponseItem, 0, len(templates))
+	for _, item := range templates {
+		items = append(items, toRecurringTemplateResponseItem(item))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+func (s *Server) handleCreateRecurringTemplate(w http.ResponseWriter, r *http.Request) {
+	var req recurringTemplateCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	id, err := s.recurring.Create(r.Context(), service.CreateRecurringTemplateInput{
+		ActorUserID: uint64(user.ID),
+		EntryType:   req.EntryType,
+		Amount:      req.Amount,
+		Donor:       req.Donor,
+		Purpose:     req.Purpose,
+		HandledBy:   req.HandledBy,
+		Category:    req.Category,
+		Frequency:   req.Frequency,
+		DayOfMonth:  req.DayOfMonth,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+	})
+	if err != nil {
+		if isValidationErr(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, "failed to create recurring template")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"id": id})
+}
+
+func (s *Server) handleUpdateRecurringTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid recurring template id")
+	if !ok {
+		return
+	}
+	var req recurringTemplateUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err := s.recurring.Update(r.Context(), service.UpdateRecurringTemplateInput{
+		ID:      id,
+		Active:  req.Active,
+		EndDate: req.EndDate,
+	})
+	if err != nil {
+		handleRecurringTemplateError(w, err, "failed to update recurring template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteRecurringTemplate(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid recurring template id")
+	if !ok {
+		return
+	}
+
+	if err := s.recurring.Delete(r.Context(), id); err != nil {
+		handleRecurringTemplateError(w, err, "failed to delete recurring template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runRecurringScheduler books due recurring entries once at startup and then
+// on every interval tick until ctx is cancelled.
+func (s *Server) runRecurringScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		created, err := s.recurring.MaterializeDue(ctx, time.Now())
+		if err != nil {
+			log.Printf("recurring scheduler: %v", err)
+		}
+		if created > 0 {
+			log.Printf("recurring scheduler: booked or queued %d entries", created)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func handleRecurringTemplateError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrRecurringTemplateNotFound):
+		writeErr(w, http.StatusNotFound, "recurring template not found")
+	default:
+		if isValidationErr(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func toRecurringTemplateResponseItem(item model.RecurringTemplate) recurringTemplateResponseItem {
+	out := recurringTemplateResponseItem{
+		ID:         item.ID,
+		EntryType:  string(item.EntryType),
+		Amount:     item.Amount,
+		Donor:      item.Donor,
+		Purpose:    item.Purpose,
+		HandledBy:  item.HandledBy,
+		Category:   string(item.Category),
+		Frequency:  string(item.Frequency),
+		DayOfMonth: item.DayOfMonth,
+		StartDate:  item.StartDate.Format("2006-01-02"),
+		Active:     item.Active,
+		LastPeriod: item.LastPeriod,
+		CreatedBy:  item.CreatedBy,
+		CreatedAt:  item.CreatedAt.Format(time.RFC3339),
+	}
+	if item.EndDate != nil {
+		endDate := item.EndDate.Format("2006-01-02")
+		out.EndDate = &endDate
+	}
+	return out
+}
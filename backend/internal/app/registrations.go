@@ -0,0 +1,112 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	userStatusActive   = "active"
+	userStatusPending  = "pending"
+	userStatusRejected = "rejected"
+)
+
+// handleListRegistrations lists accounts awaiting approval, oldest first so
+// the queue is worked in order. status=rejected shows refused ones instead.
+func (s *Server) handleListRegistrations(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, ok := parsePageQuery(w, r)
+	if !ok {
+		return
+	}
+
+	filter := userListFilter{
+		Status: userStatusPending,
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	}
+	switch status := strings.TrimSpace(r.URL.Query().Get("status")); status {
+	case "", userStatusPending:
+	case userStatusRejected:
+		filter.Status = status
+	default:
+		writeErr(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	users, err := listUsers(r.Context(), s.db, filter)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list registrations")
+		return
+	}
+	total, err := countUsers(r.Context(), s.db, filter)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list registrations")
+		return
+	}
+
+	items := make([]userResponseItem, 0, len(users))
+	for _, user := range users {
+		items = append(items, toUserResponseItem(user))
+	}
+	writePage(w, items, page, pageSize, total)
+}
+
+// handleApproveRegistration lets a pending account sign in. A rejected
+// registration can be approved later if it was refused by mistake.
+func (s *Server) handleApproveRegistration(w http.ResponseWriter, r *http.Request) {
+	s.reviewRegistration(w, r, []string{userStatusPending, userStatusRejected}, userStatusActive)
+}
+
+// handleRejectRegistration refuses a pending account. The account is kept,
+// so the phone number cannot simply register again.
+func (s *Server) handleRejectRegistration(w http.ResponseWriter, r *http.Request) {
+	s.reviewRegistration(w, r, []string{userStatusPending}, userStatusRejected)
+}
+
+func (s *Server) reviewRegistration(w http.ResponseWriter, r *http.Request, from []string, status string) {
+	id, ok := parsePathID(w, r, "invalid user id")
+	if !ok {
+		return
+	}
+
+	reviewer := authUserFromContext(r.Context())
+	err := reviewRegistration(r.Context(), s.db, int64(id), from, status, reviewer.ID, time.Now())
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeErr(w, http.StatusNotFound, "user not found")
+		return
+	case errors.Is(err, errNotPending):
+		writeErr(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		writeErr(w, http.StatusInternalServerError, "failed to review registration")
+		return
+	}
+	s.tokenStates.forget(int64(id))
+
+	user, err := findUserByID(r.Context(), s.db, int64(id))
+	if err != nil {
+		handleUserError(w, err, "failed to get user")
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserResponseItem(user))
+}
+
+// checkAccountUsable answers 403 for accounts that may not get tokens,
+// with a message that tells the user why.
+func checkAccountUsable(w http.ResponseWriter, user User) bool {
+	switch {
+	case user.DisabledAt != nil:
+		writeErr(w, http.StatusForbidden, "account is disabled")
+	case user.Status == userStatusPending:
+		writeErr(w, http.StatusForbidden, "account is pending approval")
+	case user.Status == userStatusRejected:
+		writeErr(w, http.StatusForbidden, "registration was rejected")
+	default:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+	"propets/backend/internal/service"
+)
+
+type reimbursementCreateRequest struct {
+	Amount     string `json:"amount"`
+	Purpose    string `json:"purpose"`
+	Category   string `json:"category"`
+	OccurredAt string `json:"occurredAt"`
+	ReceiptURL string `json:"receiptUrl"`
+}
+
+type reimbursementApproveRequest struct {
+	HandledBy string `json:"handledBy"`
+}
+
+type reviewRejectRequest struct {
+	Reason string `json:"reason"`
+}
+
+type reimbursementResponseItem struct {
+	ID          uint64      `json:"id"`
+	RequesterID uint64      `json:"requester_id"`
+	Amount      model.Money `json:"amount"`
+	Purpose     string      `json:"purpose"`
+	Category    string      `json:"category"`
+	OccurredAt  string      `json:"occurred_at"`
+	ReceiptURL  string      `json:"receipt_url"`
+	Status      string      `json:"status"`
+	ReviewedBy  *uint64     `json:"reviewed_by"`
+	ReviewedAt  *string     `json:"reviewed_at"`
+	ReviewNote  string      `json:"review_note"`
+	EntryID     *uint64     `json:"entry_id"`
+	CreatedAt   string      `json:"created_at"`
+}
+
+func (s *Server) handleCreateReimbursement(w http.ResponseWriter, r *http.Request) {
+	var req reimbursementCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	id, err := s.reimbursements.Submit(r.Context(), service.SubmitReimbursementInput{
+		RequesterID: uint64(user.ID),
+		Amount:      req.Amount,
+		Purpose:     req.Purpose,
+		Category:    req.Category,
+		OccurredAt:  req.OccurredAt,
+		ReceiptURL:  req.ReceiptURL,
+	})
+	if err != nil {
+		if isValidationErr(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, "failed to submit reimbursement")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"id": id, "status": model.ReimbursementStatusPending})
+}
+
+func (s *Server) handleListReimbursements(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePositiveQueryInt(r, "page")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	pageSize, err := parsePositiveQueryInt(r, "pageSize")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Members only ever see their own requests; reviewers and auditors see
+	// everyone's.
+	user := authUserFromContext(r.Context())
+	input := service.ListReimbursementsInput{
+		Status:   r.URL.Query().Get("status"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if !user.can(permAuditRead) {
+		input.RequesterID = uint64(user.ID)
+	}
+
+	result, err := s.reimbursements.List(r.Context(), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidReimbursementStatus):
+			writeErr(w, http.StatusBadRequest, "invalid status")
+		case errors.Is(err, service.ErrInvalidPage):
+			writeErr(w, http.StatusBadRequest, "invalid page")
+		case errors.Is(err, service.ErrInvalidPageSize):
+			writeErr(w, http.StatusBadRequest, "invalid pageSize")
+		default:
+			writeErr(w, http.StatusInternalServerError, "failed to list reimbursements")
+		}
+		return
+	}
+
+	items := make([]reimbursementResponseItem, 0, len(result.Items))
+	for _, item := range result.Items {
+		items = append(items, toReimbursementResponseItem(item))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"items":       items,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total":       result.Total,
+		"total_pages": result.TotalPages,
+	})
+}
+
+func (s *Server) handleGetReimbursement(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid reimbursement id")
+	if !ok {
+		return
+	}
+
+	item, err := s.reimbursements.Get(r.Context(), id)
+	if err != nil {
+		handleReimbursementError(w, err, "failed to fetch reimbursement")
+		return
+	}
+	user := authUserFromContext(r.Context())
+	if !user.can(permAuditRead) && item.RequesterID != uint64(user.ID) {
+		writeErr(w, http.StatusNotFound, "reimbursement not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toReimbursementResponseItem(item))
+}
+
+func (s *Server) handleApproveReimbursement(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid reimbursement id")
+	if !ok {
+		return
+	}
+	var req reimbursementApproveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	item, err := s.reimbursements.Get(r.Context(), id)
+	if err != nil {
+		handleReimbursementError(w, err, "failed to approve reimbursement")
+		return
+	}
+	handledBy := strings.TrimSpace(req.HandledBy)
+	if handledBy == "" {
+		requester, err := findUserByID(r.Context(), s.db, int64(item.RequesterID))
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "failed to approve reimbursement")
+			return
+		}
+		handledBy = requester.DisplayName
+		if handledBy == "" {
+			// The description is readable by every role and can be public,
+			// so never fall back to the phone number.
+			handledBy = fmt.Sprintf("user #%d", requester.ID)
+		}
+	}
+
+	user := authUserFromContext(r.Context())
+	result, err := s.reimbursements.Approve(r.Context(), service.ApproveReimbursementInput{
+		ReimbursementID: id,
+		ReviewerID:      uint64(user.ID),
+		HandledBy:       handledBy,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrReimbursementNotFound),
+			errors.Is(err, repository.ErrReimbursementNotPending),
+			errors.Is(err, service.ErrReimbursementChangeRejected),
+			errors.Is(err, service.ErrSameApprover):
+			handleReimbursementError(w, err, "failed to approve reimbursement")
+		default:
+			handleLedgerWriteError(w, err)
+		}
+		return
+	}
+
+	if result.Pending {
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{"id": id, "status": model.ReimbursementStatusUnbooked, "changeId": result.ChangeID})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": model.ReimbursementStatusApproved, "entryId": result.EntryID})
+}
+
+func (s *Server) handleRejectReimbursement(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid reimbursement id")
+	if !ok {
+		return
+	}
+	var req reviewRejectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	if err := s.reimbursements.Reject(r.Context(), id, uint64(user.ID), req.Reason); err != nil {
+		handleReimbursementError(w, err, "failed to reject reimbursement")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "status": model.ReimbursementStatusRejected})
+}
+
+func handleReimbursementError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrReimbursementNotFound):
+		writeErr(w, http.StatusNotFound, "reimbursement not found")
+	case errors.Is(err, repository.ErrReimbursementNotPending):
+		writeErr(w, http.StatusConflict, "reimbursement already reviewed")
+	case errors.Is(err, service.ErrReimbursementChangeRejected):
+		writeErr(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrSameApprover):
+		writeErr(w, http.StatusForbidden, err.Error())
+	default:
+		if isValidationErr(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func toReimbursementResponseItem(item model.Reimbursement) reimbursementResponseItem {
+	out := reimbursementResponseItem{
+		ID:          item.ID,
+		RequesterID: item.RequesterID,
+		Amount:      item.Amount,
+		Purpose:     item.Purpose,
+		Category:    string(item.Category),
+		OccurredAt:  item.OccurredAt.Format(time.RFC3339),
+		ReceiptURL:  item.ReceiptURL,
+		Status:      string(item.Status),
+		ReviewedBy:  item.ReviewedBy,
+		ReviewNote:  item.ReviewNote,
+		EntryID:     item.EntryID,
+		CreatedAt:   item.CreatedAt.Format(time.RFC3339),
+	}
+	if item.ReviewedAt != nil {
+		reviewedAt := item.ReviewedAt.Format(time.RFC3339)
+		out.ReviewedAt = &reviewedAt
+	}
+	return out
+}
+
+func parsePathID(w http.ResponseWriter, r *http.Request, message string) (uint64, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		writeErr(w, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return id, true
+}
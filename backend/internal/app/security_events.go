@@ -0,0 +1,47 @@
+package app
+
+import (
+	"net/http"
+	"time"
+)
+
+type securityEventResponseItem struct {
+	ID        int64  `json:"id"`
+	UserID    *int64 `json:"user_id"`
+	EventType string `json:"event_type"`
+	Detail    string `json:"detail"`
+	IP        string `json:"ip"`
+	CreatedAt string `json:"created_at"`
+}
+
+// handleListSecurityEvents lists recorded security events, newest first.
+func (s *Server) handleListSecurityEvents(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, ok := parsePageQuery(w, r)
+	if !ok {
+		return
+	}
+
+	events, err := listSecurityEvents(r.Context(), s.db, pageSize, (page-1)*pageSize)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list security events")
+		return
+	}
+	total, err := countSecurityEvents(r.Context(), s.db)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list security events")
+		return
+	}
+
+	items := make([]securityEventResponseItem, 0, len(events))
+	for _, e := range events {
+		items = append(items, securityEventResponseItem{
+			ID:        e.ID,
+			UserID:    e.UserID,
+			EventType: e.EventType,
+			Detail:    e.Detail,
+			IP:        e.IP,
+			CreatedAt: e.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	writePage(w, items, page, pageSize, total)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	"propets/backend/internal/model"
 	"propets/backend/internal/repository"
 	"propets/backend/internal/service"
+	"propets/backend/internal/sms"
 )
 
 type contextKey string
@@ -24,24 +26,41 @@ type contextKey string
 const userContextKey contextKey = "authUser"
 
 type Server struct {
-	cfg           Config
-	db            *sql.DB
-	tokens        *TokenManager
-	ledgerWriter  *service.LedgerService
-	ledgerQueries *service.LedgerQueryService
-	mux           *http.ServeMux
-	http          *http.Server
+	cfg             Config
+	db              *sql.DB
+	tokens          *TokenManager
+	ledgerWriter    *service.LedgerService
+	ledgerQueries   *service.LedgerQueryService
+	budgets         *service.BudgetService
+	reimbursements  *service.ReimbursementService
+	donationReports *service.DonationReportService
+	recurring       *service.RecurringService
+	approvals       *service.LedgerApprovalService
+	comments        *service.CommentService
+	donorPrivacy    service.DonorPrivacy
+	sms             sms.Sender
+	smsIPLimiter    *rateLimiter
+	trustedProxies  []*net.IPNet
+	tokenStates     *tokenStateCache
+	stopScheduler   context.CancelFunc
+	mux             *http.ServeMux
+	http            *http.Server
 }
 
 type authContextUser struct {
 	ID    int64
 	Phone string
 	Role  string
+	// SessionID is the session the access token was issued in; empty for
+	// tokens from before sessions were named.
+	SessionID string
 }
 
 type authRequest struct {
 	Phone    string `json:"phone"`
 	Password string `json:"password"`
+	// InviteCode is read at registration only.
+	InviteCode string `json:"inviteCode"`
 }
 
 type refreshRequest struct {
@@ -53,27 +72,40 @@ type amountValidationRequest struct {
 }
 
 type donationCreateRequest struct {
-	Donor     string `json:"donor"`
-	DonatedAt string `json:"donatedAt"`
-	Amount    string `json:"amount"`
-	RequestID string `json:"requestId"`
+	Donor     string   `json:"donor"`
+	DonatedAt string   `json:"donatedAt"`
+	Amount    string   `json:"amount"`
+	Tags      []string `json:"tags"`
+	RequestID string   `json:"requestId"`
 }
 
 type expenseCreateRequest struct {
-	Purpose    string `json:"purpose"`
-	Amount     string `json:"amount"`
-	HandledBy  string `json:"handledBy"`
-	OccurredAt string `json:"occurredAt"`
-	RequestID  string `json:"requestId"`
+	Purpose    string               `json:"purpose"`
+	Amount     string               `json:"amount"`
+	HandledBy  string               `json:"handledBy"`
+	OccurredAt string               `json:"occurredAt"`
+	Category   string               `json:"category"`
+	Lines      []expenseLineRequest `json:"lines"`
+	Tags       []string             `json:"tags"`
+	RequestID  string               `json:"requestId"`
+}
+
+type expenseLineRequest struct {
+	Description string `json:"description"`
+	Amount      string `json:"amount"`
+	Category    string `json:"category"`
 }
 
 type ledgerEntryUpdateRequest struct {
-	Donor      string `json:"donor"`
-	DonatedAt  string `json:"donatedAt"`
-	Purpose    string `json:"purpose"`
-	HandledBy  string `json:"handledBy"`
-	OccurredAt string `json:"occurredAt"`
-	Amount     string `json:"amount"`
+	Donor      string               `json:"donor"`
+	DonatedAt  string               `json:"donatedAt"`
+	Purpose    string               `json:"purpose"`
+	HandledBy  string               `json:"handledBy"`
+	OccurredAt string               `json:"occurredAt"`
+	Category   string               `json:"category"`
+	Amount     string               `json:"amount"`
+	Lines      []expenseLineRequest `json:"lines"`
+	Tags       []string             `json:"tags"`
 }
 
 type responseError struct {
@@ -86,6 +118,34 @@ type healthResponse struct {
 }
 
 func NewServer(cfg Config) (*Server, error) {
+	loc, err := cfg.Location()
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORG_TIMEZONE: %w", err)
+	}
+	trustedProxies, err := cfg.TrustedProxyNets()
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	jwtKeys, err := ParseJWTKeys(cfg.JWTKeys, cfg.JWTSecret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_KEYS: %w", err)
+	}
+	tokens, err := NewTokenManager(jwtKeys, cfg.JWTSigningKeyID, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_SIGNING_KEY_ID: %w", err)
+	}
+	// A rotated refresh token must outlive its row, or replaying it after the
+	// purge no longer revokes its family.
+	if cfg.RefreshTokenRetention <= 0 || cfg.RefreshTokenRetention < cfg.RefreshTokenTTL {
+		return nil, errors.New("invalid REFRESH_TOKEN_RETENTION_DAY: must be positive and cover REFRESH_TOKEN_TTL_HOUR")
+	}
+	if cfg.IdempotencyKeyTTL <= 0 {
+		return nil, errors.New("invalid IDEMPOTENCY_KEY_TTL_DAY: must be positive")
+	}
+	if cfg.SecurityEventRetention < 0 {
+		return nil, errors.New("invalid SECURITY_EVENT_RETENTION_DAY: must not be negative")
+	}
+
 	db, err := sql.Open("mysql", cfg.DSN())
 	if err != nil {
 		return nil, err
@@ -100,14 +160,50 @@ func NewServer(cfg Config) (*Server, error) {
 		return nil, err
 	}
 
-	s := &Server{
-		cfg:           cfg,
-		db:            db,
-		tokens:        NewTokenManager(cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL),
-		ledgerWriter:  service.NewLedgerService(repository.NewSQLLedgerRepository(db)),
-		ledgerQueries: service.NewLedgerQueryService(repository.NewSQLLedgerRepository(db)),
-		mux:           http.NewServeMux(),
+	var approvalThreshold model.Money
+	if cfg.ApprovalThreshold != "" {
+		approvalThreshold, err = model.ParseAmount(cfg.ApprovalThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DUAL_APPROVAL_THRESHOLD: %w", err)
+		}
+	}
+
+	donorPrivacy, err := service.ParseDonorPrivacy(cfg.PublicDonorPrivacy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PUBLIC_DONOR_PRIVACY: %w", err)
+	}
+
+	smsSender, err := sms.NewSender(cfg.SMSProvider, cfg.SMSFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMS_PROVIDER: %w", err)
 	}
+
+	ledgerRepo := repository.NewSQLLedgerRepository(db)
+	ledgerWriter := service.NewLedgerService(ledgerRepo, loc)
+	approvals := service.NewLedgerApprovalService(repository.NewSQLLedgerChangeRepository(db), ledgerRepo, ledgerWriter, approvalThreshold)
+	s := &Server{
+		cfg:             cfg,
+		db:              db,
+		tokens:          tokens,
+		ledgerWriter:    ledgerWriter,
+		ledgerQueries:   service.NewLedgerQueryService(ledgerRepo, loc),
+		budgets:         service.NewBudgetService(repository.NewSQLBudgetRepository(db), ledgerRepo),
+		reimbursements:  service.NewReimbursementService(repository.NewSQLReimbursementRepository(db), ledgerWriter, approvals),
+		donationReports: service.NewDonationReportService(repository.NewSQLDonationReportRepository(db), ledgerWriter),
+		recurring:       service.NewRecurringService(repository.NewSQLRecurringRepository(db), ledgerWriter, approvals),
+		approvals:       approvals,
+		comments:        service.NewCommentService(repository.NewSQLCommentRepository(db), ledgerRepo),
+		donorPrivacy:    donorPrivacy,
+		sms:             smsSender,
+		trustedProxies:  trustedProxies,
+		mux:             http.NewServeMux(),
+	}
+	if cfg.SMSSendsPerIPPerHour > 0 {
+		s.smsIPLimiter = newRateLimiter(cfg.SMSSendsPerIPPerHour, time.Hour)
+	}
+	s.tokenStates = newTokenStateCache(cfg.TokenStateCacheTTL, func(ctx context.Context, userID int64) (tokenState, error) {
+		return findTokenState(ctx, db, userID)
+	})
 	s.registerRoutes()
 	s.http = &http.Server{
 		Addr:              ":" + cfg.AppPort,
@@ -125,10 +221,21 @@ func NewServer(cfg Config) (*Server, error) {
 }
 
 func (s *Server) Close() error {
+	if s.stopScheduler != nil {
+		s.stopScheduler()
+	}
 	return s.db.Close()
 }
 
 func (s *Server) ListenAndServe() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopScheduler = cancel
+	if s.cfg.RecurringInterval > 0 {
+		go s.runRecurringScheduler(ctx, s.cfg.RecurringInterval)
+	}
+	if s.cfg.MaintenanceInterval > 0 {
+		go s.runMaintenanceScheduler(ctx, s.cfg.MaintenanceInterval)
+	}
 	log.Printf("backend listening on %s", s.http.Addr)
 	return s.http.ListenAndServe()
 }
@@ -141,19 +248,81 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("POST /api/auth/login", s.handleLogin)
 	s.mux.HandleFunc("POST /api/auth/refresh", s.handleRefresh)
 	s.mux.HandleFunc("POST /api/auth/logout", s.handleLogout)
+	s.mux.HandleFunc("POST /api/auth/reset", s.handleResetPassword)
+	s.mux.HandleFunc("GET /api/auth/jwks.json", s.handleJWKS)
+	s.mux.HandleFunc("POST /api/auth/sms/send", s.handleSendSMSCode)
+	s.mux.HandleFunc("POST /api/auth/sms/verify", s.handleVerifySMSCode)
+	s.mux.Handle("GET /api/me", s.withAuth(http.HandlerFunc(s.handleGetMe)))
+	s.mux.Handle("PATCH /api/me", s.withAuth(http.HandlerFunc(s.handleUpdateMe)))
+	s.mux.Handle("POST /api/me/password", s.withAuth(http.HandlerFunc(s.handleChangePassword)))
+	s.mux.Handle("GET /api/me/sessions", s.withAuth(http.HandlerFunc(s.handleListMySessions)))
+	s.mux.Handle("DELETE /api/me/sessions", s.withAuth(http.HandlerFunc(s.handleRevokeMySessions)))
+	s.mux.Handle("DELETE /api/me/sessions/{id}", s.withAuth(http.HandlerFunc(s.handleRevokeMySession)))
 	s.mux.HandleFunc("POST /api/ledger/validate-amount", s.handleValidateAmount)
-	s.mux.Handle("POST /api/ledger/donations", s.withAuth(s.withRole("admin", http.HandlerFunc(s.handleCreateDonation))))
-	s.mux.Handle("POST /api/ledger/expenses", s.withAuth(s.withRole("admin", http.HandlerFunc(s.handleCreateExpense))))
-	s.mux.Handle("PATCH /api/ledger/entries/{id}", s.withAuth(s.withRole("admin", http.HandlerFunc(s.handleUpdateEntry))))
-	s.mux.Handle("DELETE /api/ledger/entries/{id}", s.withAuth(s.withRole("admin", http.HandlerFunc(s.handleDeleteEntry))))
+	s.mux.Handle("POST /api/ledger/donations", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleCreateDonation))))
+	s.mux.Handle("POST /api/ledger/expenses", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleCreateExpense))))
+	s.mux.Handle("PATCH /api/ledger/entries/{id}", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleUpdateEntry))))
+	s.mux.Handle("DELETE /api/ledger/entries/{id}", s.withAuth(s.withPermission(permLedgerDelete, http.HandlerFunc(s.handleDeleteEntry))))
 	s.mux.Handle("GET /api/summary", s.withAuth(http.HandlerFunc(s.handleSummary)))
 	s.mux.Handle("GET /api/summary/monthly", s.withAuth(http.HandlerFunc(s.handleMonthlyStatistics)))
+	s.mux.Handle("GET /api/summary/forecast", s.withAuth(http.HandlerFunc(s.handleForecast)))
 	s.mux.Handle("GET /api/ledger/entries", s.withAuth(http.HandlerFunc(s.handleLedgerEntries)))
-
-	s.mux.Handle("GET /api/admin/ping", s.withAuth(s.withRole("admin", http.HandlerFunc(s.handleAdminPing))))
+	s.mux.Handle("GET /api/ledger/entries/{id}/history", s.withAuth(s.withPermission(permAuditRead, http.HandlerFunc(s.handleLedgerEntryHistory))))
+	s.mux.Handle("GET /api/ledger/entries/{id}/comments", s.withAuth(http.HandlerFunc(s.handleListComments)))
+	s.mux.Handle("POST /api/ledger/entries/{id}/comments", s.withAuth(s.withPermission(permLedgerSubmit, http.HandlerFunc(s.handleCreateComment))))
+	s.mux.Handle("PATCH /api/ledger/comments/{id}", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleModerateComment))))
+	s.mux.Handle("GET /api/ledger/changes", s.withAuth(s.withPermission(permAuditRead, http.HandlerFunc(s.handleListLedgerChanges))))
+	s.mux.Handle("GET /api/ledger/changes/{id}", s.withAuth(s.withPermission(permAuditRead, http.HandlerFunc(s.handleGetLedgerChange))))
+	s.mux.Handle("POST /api/ledger/changes/{id}/approve", s.withAuth(s.withPermission(permLedgerApprove, http.HandlerFunc(s.handleApproveLedgerChange))))
+	s.mux.Handle("POST /api/ledger/changes/{id}/reject", s.withAuth(s.withPermission(permLedgerApprove, http.HandlerFunc(s.handleRejectLedgerChange))))
+	s.mux.Handle("GET /api/tags", s.withAuth(http.HandlerFunc(s.handleListTags)))
+	s.mux.Handle("GET /api/budgets", s.withAuth(http.HandlerFunc(s.handleListBudgets)))
+	s.mux.Handle("PUT /api/budgets/{category}", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleSetBudget))))
+	s.mux.Handle("DELETE /api/budgets/{category}", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleDeleteBudget))))
+	s.mux.Handle("POST /api/reimbursements", s.withAuth(s.withPermission(permLedgerSubmit, http.HandlerFunc(s.handleCreateReimbursement))))
+	s.mux.Handle("GET /api/reimbursements", s.withAuth(http.HandlerFunc(s.handleListReimbursements)))
+	s.mux.Handle("GET /api/reimbursements/{id}", s.withAuth(http.HandlerFunc(s.handleGetReimbursement)))
+	s.mux.Handle("POST /api/reimbursements/{id}/approve", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleApproveReimbursement))))
+	s.mux.Handle("POST /api/reimbursements/{id}/reject", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleRejectReimbursement))))
+	s.mux.Handle("POST /api/donation-reports", s.withAuth(s.withPermission(permLedgerSubmit, http.HandlerFunc(s.handleCreateDonationReport))))
+	s.mux.Handle("GET /api/donation-reports", s.withAuth(http.HandlerFunc(s.handleListDonationReports)))
+	s.mux.Handle("GET /api/donation-reports/{id}", s.withAuth(http.HandlerFunc(s.handleGetDonationReport)))
+	s.mux.Handle("POST /api/donation-reports/{id}/confirm", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleConfirmDonationReport))))
+	s.mux.Handle("POST /api/donation-reports/{id}/reject", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleRejectDonationReport))))
+	s.mux.Handle("GET /api/recurring-templates", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleListRecurringTemplates))))
+	s.mux.Handle("POST /api/recurring-templates", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleCreateRecurringTemplate))))
+	s.mux.Handle("PATCH /api/recurring-templates/{id}", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleUpdateRecurringTemplate))))
+	s.mux.Handle("DELETE /api/recurring-templates/{id}", s.withAuth(s.withPermission(permLedgerWrite, http.HandlerFunc(s.handleDeleteRecurringTemplate))))
+	s.mux.Handle("GET /api/users", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleListUsers))))
+	s.mux.Handle("GET /api/users/{id}", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleGetUser))))
+	s.mux.Handle("PATCH /api/users/{id}", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleUpdateUser))))
+	s.mux.Handle("GET /api/security-events", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleListSecurityEvents))))
+	s.mux.Handle("GET /api/login-lockouts", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleListLoginLockouts))))
+	s.mux.Handle("DELETE /api/login-lockouts/{scope}/{subject}", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleClearLoginLockout))))
+	s.mux.Handle("GET /api/users/{id}/sessions", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleListUserSessions))))
+	s.mux.Handle("DELETE /api/users/{id}/sessions", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleRevokeUserSessions))))
+	s.mux.Handle("DELETE /api/users/{id}/sessions/{sessionId}", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleRevokeUserSession))))
+	s.mux.Handle("GET /api/registrations", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleListRegistrations))))
+	s.mux.Handle("POST /api/registrations/{id}/approve", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleApproveRegistration))))
+	s.mux.Handle("POST /api/registrations/{id}/reject", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleRejectRegistration))))
+	s.mux.Handle("GET /api/invite-codes", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleListInviteCodes))))
+	s.mux.Handle("POST /api/invite-codes", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleCreateInviteCode))))
+	s.mux.Handle("DELETE /api/invite-codes/{id}", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleRevokeInviteCode))))
+	s.mux.Handle("POST /api/users/{id}/password-reset", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleCreatePasswordReset))))
+
+	if s.cfg.PublicModeEnabled {
+		s.registerPublicRoutes()
+	}
+
+	s.mux.Handle("GET /api/admin/ping", s.withAuth(s.withPermission(permUsersManage, http.HandlerFunc(s.handleAdminPing))))
 	s.mux.HandleFunc("POST /api/admin/init", s.handleAdminInit)
 }
 
+type summaryResponse struct {
+	service.MonthlySummary
+	Categories []service.CategoryBudgetSummary `json:"categories"`
+}
+
 func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
 	month := strings.TrimSpace(r.URL.Query().Get("month"))
 	summary, err := s.ledgerQueries.GetMonthlySummary(r.Context(), month)
@@ -167,7 +336,13 @@ func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, summary)
+	categories, err := s.budgets.MonthlyBudgetReport(r.Context(), month)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to fetch budget summary")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summaryResponse{MonthlySummary: summary, Categories: categories})
 }
 
 type monthlyStatisticsResponse struct {
@@ -184,33 +359,136 @@ func (s *Server) handleMonthlyStatistics(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, monthlyStatisticsResponse{Items: items})
 }
 
+func (s *Server) handleForecast(w http.ResponseWriter, r *http.Request) {
+	months, err := parsePositiveQueryInt(r, "months")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	window, err := parsePositiveQueryInt(r, "window")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	seasonal := false
+	if raw := strings.TrimSpace(r.URL.Query().Get("seasonal")); raw != "" {
+		seasonal, err = strconv.ParseBool(raw)
+		if err != nil {
+			writeErr(w, http.StatusBadRequest, "invalid seasonal")
+			return
+		}
+	}
+
+	forecast, err := s.ledgerQueries.ForecastBalance(r.Context(), service.ForecastInput{
+		Months:   months,
+		Window:   window,
+		Seasonal: seasonal,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidForecastMonths), errors.Is(err, service.ErrInvalidForecastWindow):
+			writeErr(w, http.StatusBadRequest, err.Error())
+		default:
+			writeErr(w, http.StatusInternalServerError, "failed to build forecast")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, forecast)
+}
+
 type ledgerEntriesResponseItem struct {
-	ID          uint64 `json:"id"`
-	UserID      uint64 `json:"user_id"`
-	EntryType   string `json:"entry_type"`
-	Amount      string `json:"amount"`
-	OccurredAt  string `json:"occurred_at"`
-	Description string `json:"description"`
-	MonthKey    string `json:"month_key"`
-	CreatedAt   string `json:"created_at"`
+	ID            uint64                        `json:"id"`
+	UserID        uint64                        `json:"user_id"`
+	RecordedBy    string                        `json:"recorded_by"`
+	EntryType     string                        `json:"entry_type"`
+	Amount        model.Money                   `json:"amount"`
+	OccurredAt    string                        `json:"occurred_at"`
+	Description   string                        `json:"description"`
+	Category      string                        `json:"category"`
+	MonthKey      string                        `json:"month_key"`
+	CreatedAt     string                        `json:"created_at"`
+	Lines         []ledgerEntryLineResponseItem `json:"lines"`
+	Tags          []string                      `json:"tags"`
+	OpenQuestions int                           `json:"open_questions"`
+	DeletedAt     *string                       `json:"deleted_at,omitempty"`
+	DeletedBy     *uint64                       `json:"deleted_by,omitempty"`
+}
+
+type ledgerEntryLineResponseItem struct {
+	ID          uint64      `json:"id"`
+	Description string      `json:"description"`
+	Amount      model.Money `json:"amount"`
+	Category    string      `json:"category"`
 }
 
 func (s *Server) handleLedgerEntries(w http.ResponseWriter, r *http.Request) {
+	// ?deleted=true lists soft-deleted entries for audit.
+	deleted := r.URL.Query().Get("deleted") == "true"
+	if deleted && !authUserFromContext(r.Context()).can(permAuditRead) {
+		writeErr(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	result, ok := s.queryLedgerEntries(w, r, deleted)
+	if !ok {
+		return
+	}
+
+	items := make([]ledgerEntriesResponseItem, 0, len(result.Items))
+	for _, entry := range result.Items {
+		item := ledgerEntriesResponseItem{
+			ID:            entry.ID,
+			UserID:        entry.UserID,
+			RecordedBy:    entry.RecorderName,
+			EntryType:     string(entry.EntryType),
+			Amount:        entry.Amount,
+			OccurredAt:    entry.OccurredAt.Format(time.RFC3339),
+			Description:   entry.Description,
+			Category:      string(entry.Category),
+			MonthKey:      entry.MonthKey,
+			CreatedAt:     entry.CreatedAt.Format(time.RFC3339),
+			Lines:         toLedgerEntryLineResponseItems(entry),
+			Tags:          entryTags(entry),
+			OpenQuestions: entry.OpenQuestions,
+			DeletedBy:     entry.DeletedBy,
+		}
+		if entry.DeletedAt != nil {
+			deletedAt := entry.DeletedAt.Format(time.RFC3339)
+			item.DeletedAt = &deletedAt
+		}
+		items = append(items, item)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"items":       items,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"total":       result.Total,
+		"total_pages": result.TotalPages,
+	})
+}
+
+// queryLedgerEntries runs the entry listing described by the query string and
+// writes the error response itself when it fails. deleted switches to the
+// soft-deleted entries.
+func (s *Server) queryLedgerEntries(w http.ResponseWriter, r *http.Request, deleted bool) (service.ListEntriesResult, bool) {
 	page, err := parsePositiveQueryInt(r, "page")
 	if err != nil {
 		writeErr(w, http.StatusBadRequest, err.Error())
-		return
+		return service.ListEntriesResult{}, false
 	}
 
 	pageSize, err := parsePositiveQueryInt(r, "pageSize")
 	if err != nil {
 		writeErr(w, http.StatusBadRequest, err.Error())
-		return
+		return service.ListEntriesResult{}, false
 	}
 
 	result, err := s.ledgerQueries.ListEntries(r.Context(), service.ListEntriesInput{
 		Month:    r.URL.Query().Get("month"),
 		Type:     r.URL.Query().Get("type"),
+		Tag:      r.URL.Query().Get("tag"),
+		Deleted:  deleted,
 		Page:     page,
 		PageSize: pageSize,
 	})
@@ -227,30 +505,40 @@ func (s *Server) handleLedgerEntries(w http.ResponseWriter, r *http.Request) {
 		default:
 			writeErr(w, http.StatusInternalServerError, "failed to list ledger entries")
 		}
+		return service.ListEntriesResult{}, false
+	}
+	return result, true
+}
+
+// entryTags never returns nil so untagged entries serialize as [].
+func entryTags(entry model.LedgerEntry) []string {
+	if entry.Tags == nil {
+		return []string{}
+	}
+	return entry.Tags
+}
+
+func (s *Server) handleListTags(w http.ResponseWriter, r *http.Request) {
+	items, err := s.ledgerQueries.ListTags(r.Context())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list tags")
 		return
 	}
 
-	items := make([]ledgerEntriesResponseItem, 0, len(result.Items))
-	for _, entry := range result.Items {
-		items = append(items, ledgerEntriesResponseItem{
-			ID:          entry.ID,
-			UserID:      entry.UserID,
-			EntryType:   string(entry.EntryType),
-			Amount:      entry.Amount,
-			OccurredAt:  entry.OccurredAt.Format(time.RFC3339),
-			Description: entry.Description,
-			MonthKey:    entry.MonthKey,
-			CreatedAt:   entry.CreatedAt.Format(time.RFC3339),
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+func toLedgerEntryLineResponseItems(entry model.LedgerEntry) []ledgerEntryLineResponseItem {
+	lines := make([]ledgerEntryLineResponseItem, 0, len(entry.Lines))
+	for _, line := range entry.Lines {
+		lines = append(lines, ledgerEntryLineResponseItem{
+			ID:          line.ID,
+			Description: line.Description,
+			Amount:      line.Amount,
+			Category:    string(entry.LineCategory(line)),
 		})
 	}
-
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"items":       items,
-		"page":        result.Page,
-		"page_size":   result.PageSize,
-		"total":       result.Total,
-		"total_pages": result.TotalPages,
-	})
+	return lines
 }
 
 func (s *Server) handleCreateDonation(w http.ResponseWriter, r *http.Request) {
@@ -266,6 +554,7 @@ func (s *Server) handleCreateDonation(w http.ResponseWriter, r *http.Request) {
 		Donor:       req.Donor,
 		DonatedAt:   req.DonatedAt,
 		Amount:      req.Amount,
+		Tags:        req.Tags,
 		RequestID:   extractRequestID(r.Header.Get("Idempotency-Key"), req.RequestID),
 	})
 	if err != nil {
@@ -284,20 +573,35 @@ func (s *Server) handleCreateExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := authUserFromContext(r.Context())
-	entryID, _, err := s.ledgerWriter.CreateExpense(r.Context(), service.ExpenseInput{
+	result, err := s.approvals.SubmitExpense(r.Context(), service.ExpenseInput{
 		ActorUserID: uint64(user.ID),
 		Purpose:     req.Purpose,
 		Amount:      req.Amount,
 		HandledBy:   req.HandledBy,
 		OccurredAt:  req.OccurredAt,
+		Category:    req.Category,
+		Lines:       toExpenseLineInputs(req.Lines),
+		Tags:        req.Tags,
 		RequestID:   extractRequestID(r.Header.Get("Idempotency-Key"), req.RequestID),
 	})
 	if err != nil {
 		handleLedgerWriteError(w, err)
 		return
 	}
+	if result.Pending {
+		writePendingChange(w, result)
+		return
+	}
 
-	writeJSON(w, http.StatusCreated, map[string]interface{}{"entryId": entryID})
+	resp := map[string]interface{}{"entryId": result.EntryID}
+	warnings, err := s.budgets.ExpenseBudgetWarnings(r.Context(), result.EntryID)
+	if err != nil {
+		log.Printf("failed to check budget for entry %d: %v", result.EntryID, err)
+	} else if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
 }
 
 func (s *Server) handleDeleteEntry(w http.ResponseWriter, r *http.Request) {
@@ -309,7 +613,8 @@ func (s *Server) handleDeleteEntry(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user := authUserFromContext(r.Context())
-	if err := s.ledgerQueries.SoftDeleteEntry(r.Context(), entryID, uint64(user.ID)); err != nil {
+	result, err := s.approvals.SubmitDelete(r.Context(), uint64(user.ID), entryID)
+	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrLedgerEntryNotFound):
 			writeErr(w, http.StatusNotFound, "entry not found")
@@ -320,6 +625,10 @@ func (s *Server) handleDeleteEntry(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if result.Pending {
+		writePendingChange(w, result)
+		return
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -338,23 +647,48 @@ func (s *Server) handleUpdateEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.ledgerWriter.UpdateLedgerEntry(r.Context(), service.UpdateLedgerEntryInput{
+	user := authUserFromContext(r.Context())
+	result, err := s.approvals.SubmitUpdate(r.Context(), uint64(user.ID), service.UpdateLedgerEntryInput{
 		EntryID:    entryID,
 		Donor:      req.Donor,
 		DonatedAt:  req.DonatedAt,
 		Purpose:    req.Purpose,
 		HandledBy:  req.HandledBy,
 		OccurredAt: req.OccurredAt,
+		Category:   req.Category,
 		Amount:     req.Amount,
+		Lines:      toExpenseLineInputs(req.Lines),
+		Tags:       req.Tags,
 	})
 	if err != nil {
 		handleLedgerWriteError(w, err)
 		return
 	}
+	if result.Pending {
+		writePendingChange(w, result)
+		return
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// toExpenseLineInputs keeps a nil slice nil so an update without "lines"
+// leaves the stored line items in place.
+func toExpenseLineInputs(lines []expenseLineRequest) []service.ExpenseLineInput {
+	if lines == nil {
+		return nil
+	}
+	out := make([]service.ExpenseLineInput, 0, len(lines))
+	for _, line := range lines {
+		out = append(out, service.ExpenseLineInput{
+			Description: line.Description,
+			Amount:      line.Amount,
+			Category:    line.Category,
+		})
+	}
+	return out
+}
+
 func handleLedgerWriteError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, repository.ErrLedgerEntryNotFound):
@@ -423,20 +757,31 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := createUser(r.Context(), s.db, req.Phone, hash, "member")
+	user, err := s.registerUser(r.Context(), req.Phone, hash, req.InviteCode)
 	if err != nil {
 		if errors.Is(err, errDuplicatePhone) {
 			writeErr(w, http.StatusConflict, "phone already registered")
 			return
 		}
+		if isInviteError(err) {
+			writeErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		writeErr(w, http.StatusInternalServerError, "failed to register")
 		return
 	}
 
+	writeRegistered(w, user)
+}
+
+// writeRegistered answers a registration. A pending account gets no tokens
+// yet; the status tells the client to wait for approval.
+func writeRegistered(w http.ResponseWriter, user User) {
 	writeJSON(w, http.StatusCreated, map[string]interface{}{
-		"id":    user.ID,
-		"phone": user.Phone,
-		"role":  user.Role,
+		"id":     user.ID,
+		"phone":  user.Phone,
+		"role":   user.Role,
+		"status": user.Status,
 	})
 }
 
@@ -445,10 +790,14 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	if !ok {
 		return
 	}
+	if !s.checkLoginLockout(w, r, req.Phone) {
+		return
+	}
 
 	user, err := findUserByPhone(r.Context(), s.db, req.Phone)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			s.recordLoginFailure(r, req.Phone)
 			writeErr(w, http.StatusUnauthorized, "invalid phone or password")
 			return
 		}
@@ -456,21 +805,60 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if !CheckPassword(user.PasswordHash, req.Password) {
+		s.recordLoginFailure(r, req.Phone)
 		writeErr(w, http.StatusUnauthorized, "invalid phone or password")
 		return
 	}
+	if !checkAccountUsable(w, user) {
+		return
+	}
+	if _, err := clearLoginAttempts(r.Context(), s.db, loginScopePhone, req.Phone); err != nil {
+		log.Printf("failed to reset login failures: %v", err)
+	}
+
+	s.writeTokenPair(w, r, user, http.StatusOK)
+}
 
-	tokenPair, _, refreshExpiresAt, err := s.tokens.GenerateTokenPair(UserClaims{UserID: user.ID, Phone: user.Phone, Role: user.Role})
+// writeTokenPair signs a new token pair for user, records the refresh token
+// as the start of a new session and writes the pair as the response.
+func (s *Server) writeTokenPair(w http.ResponseWriter, r *http.Request, user User, status int) {
+	sessionID, err := randomHex(24)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "failed to generate token")
 		return
 	}
-	if err := storeRefreshToken(r.Context(), s.db, user.ID, tokenPair.RefreshToken, refreshExpiresAt); err != nil {
+	tokenPair, record, err := s.newTokenPair(r, user, sessionID)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to generate token")
+		return
+	}
+	if err := storeRefreshToken(r.Context(), s.db, record); err != nil {
 		writeErr(w, http.StatusInternalServerError, "failed to persist refresh token")
 		return
 	}
+	// The cached state does not know the new session yet.
+	s.tokenStates.forget(user.ID)
 
-	writeJSON(w, http.StatusOK, tokenPair)
+	writeJSON(w, status, tokenPair)
+}
+
+// newTokenPair signs tokens for user within session sessionID and describes
+// the refresh token to store, including the device it was issued to.
+func (s *Server) newTokenPair(r *http.Request, user User, sessionID string) (TokenPair, refreshTokenRecord, error) {
+	tokenPair, jti, expiresAt, err := s.tokens.GenerateTokenPair(UserClaims{UserID: user.ID, Phone: user.Phone, Role: user.Role, SessionID: sessionID, TokenVersion: user.TokenVersion})
+	if err != nil {
+		return TokenPair{}, refreshTokenRecord{}, err
+	}
+	return tokenPair, refreshTokenRecord{
+		UserID:    user.ID,
+		Token:     tokenPair.RefreshToken,
+		JTI:       jti,
+		FamilyID:  sessionID,
+		UserAgent: r.UserAgent(),
+		IP:        s.clientIP(r),
+		IssuedAt:  time.Now(),
+		ExpiresAt: expiresAt,
+	}, nil
 }
 
 func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
@@ -493,15 +881,6 @@ func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusUnauthorized, "invalid refresh token type")
 		return
 	}
-	active, err := isRefreshTokenActive(r.Context(), s.db, req.RefreshToken)
-	if err != nil {
-		writeErr(w, http.StatusInternalServerError, "failed to validate refresh token")
-		return
-	}
-	if !active {
-		writeErr(w, http.StatusUnauthorized, "refresh token is revoked or expired")
-		return
-	}
 
 	sub, _ := claims["sub"].(string)
 	userID, err := strconv.ParseInt(sub, 10, 64)
@@ -514,18 +893,40 @@ func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusUnauthorized, "user not found")
 		return
 	}
-
-	if err := revokeRefreshToken(r.Context(), s.db, req.RefreshToken); err != nil {
-		writeErr(w, http.StatusInternalServerError, "failed to rotate refresh token")
+	if !checkAccountUsable(w, user) {
 		return
 	}
-	tokenPair, _, refreshExpiresAt, err := s.tokens.GenerateTokenPair(UserClaims{UserID: user.ID, Phone: user.Phone, Role: user.Role})
+
+	// Tokens issued before sessions were named carry no sid.
+	sessionID, _ := claims["sid"].(string)
+	if sessionID == "" {
+		sessionID, err = findRefreshTokenFamily(r.Context(), s.db, req.RefreshToken)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeErr(w, http.StatusUnauthorized, errRefreshTokenInvalid.Error())
+			return
+		}
+		if err != nil {
+			writeErr(w, http.StatusInternalServerError, "failed to rotate refresh token")
+			return
+		}
+	}
+	tokenPair, next, err := s.newTokenPair(r, user, sessionID)
 	if err != nil {
 		writeErr(w, http.StatusInternalServerError, "failed to generate token")
 		return
 	}
-	if err := storeRefreshToken(r.Context(), s.db, user.ID, tokenPair.RefreshToken, refreshExpiresAt); err != nil {
-		writeErr(w, http.StatusInternalServerError, "failed to persist refresh token")
+	err = rotateRefreshToken(r.Context(), s.db, req.RefreshToken, next, s.clientIP(r), time.Now())
+	if errors.Is(err, errRefreshTokenReused) {
+		// The whole family was revoked; its access tokens go with it.
+		s.tokenStates.forget(user.ID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, errRefreshTokenInvalid), errors.Is(err, errRefreshTokenReused):
+			writeErr(w, http.StatusUnauthorized, err.Error())
+		default:
+			writeErr(w, http.StatusInternalServerError, "failed to rotate refresh token")
+		}
 		return
 	}
 
@@ -542,7 +943,8 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusBadRequest, "refreshToken is required")
 		return
 	}
-	if _, err := s.tokens.ParseToken(req.RefreshToken); err != nil {
+	claims, err := s.tokens.ParseToken(req.RefreshToken)
+	if err != nil {
 		writeErr(w, http.StatusUnauthorized, "invalid refresh token")
 		return
 	}
@@ -550,9 +952,21 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 		writeErr(w, http.StatusInternalServerError, "failed to logout")
 		return
 	}
+	// The session's access token stops working now, not after the cache ttl.
+	sub, _ := claims["sub"].(string)
+	if userID, err := strconv.ParseInt(sub, 10, 64); err == nil {
+		s.tokenStates.forget(userID)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleJWKS publishes the Ed25519 verification keys so other tools can check
+// tokens without holding a secret. With only HS256 keys the set is empty.
+func (s *Server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, map[string]interface{}{"keys": s.tokens.PublicKeys()})
+}
+
 func (s *Server) handleAdminPing(w http.ResponseWriter, r *http.Request) {
 	user := authUserFromContext(r.Context())
 	writeJSON(w, http.StatusOK, map[string]string{
@@ -643,22 +1057,46 @@ func (s *Server) withAuth(next http.Handler) http.Handler {
 			writeErr(w, http.StatusUnauthorized, "invalid token subject")
 			return
 		}
+		version, _ := claims["ver"].(float64)
+		sessionID, _ := claims["sid"].(string)
+		if !s.checkTokenState(w, r, userID, int64(version), sessionID) {
+			return
+		}
 		phone, _ := claims["phone"].(string)
 		role, _ := claims["role"].(string)
-		ctx := context.WithValue(r.Context(), userContextKey, authContextUser{ID: userID, Phone: phone, Role: role})
+		ctx := context.WithValue(r.Context(), userContextKey, authContextUser{ID: userID, Phone: phone, Role: role, SessionID: sessionID})
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func (s *Server) withRole(requiredRole string, next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		user := authUserFromContext(r.Context())
-		if user.Role != requiredRole {
-			writeErr(w, http.StatusForbidden, "forbidden")
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// checkTokenState rejects access tokens of disabled accounts, tokens issued
+// before the user's role, status or password last changed and tokens of a
+// session that was signed out. Tokens from before versions were introduced
+// carry no "ver" and count as version 0; tokens without a session id are only
+// checked against the version.
+func (s *Server) checkTokenState(w http.ResponseWriter, r *http.Request, userID, version int64, sessionID string) bool {
+	state, err := s.tokenStates.getSession(r.Context(), userID, sessionID, time.Now())
+	if errors.Is(err, sql.ErrNoRows) {
+		writeErr(w, http.StatusUnauthorized, "user not found")
+		return false
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to check token")
+		return false
+	}
+	if state.Disabled {
+		writeErr(w, http.StatusForbidden, "account is disabled")
+		return false
+	}
+	if version != state.Version {
+		writeErr(w, http.StatusUnauthorized, "token is no longer valid, please refresh")
+		return false
+	}
+	if sessionID != "" && !state.Sessions[sessionID] {
+		writeErr(w, http.StatusUnauthorized, "session has been signed out")
+		return false
+	}
+	return true
 }
 
 func authUserFromContext(ctx context.Context) authContextUser {
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+type sessionResponseItem struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	SignedInAt string `json:"signed_in_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
+// handleListMySessions lists the devices the caller is signed in on, most
+// recently used first. The session the request came from is marked current.
+func (s *Server) handleListMySessions(w http.ResponseWriter, r *http.Request) {
+	authUser := authUserFromContext(r.Context())
+	s.writeSessions(w, r, authUser.ID, authUser.SessionID)
+}
+
+// handleRevokeMySession signs the caller out on one device, voiding both its
+// refresh token and the access token it holds.
+func (s *Server) handleRevokeMySession(w http.ResponseWriter, r *http.Request) {
+	s.revokeOneSession(w, r, authUserFromContext(r.Context()).ID, r.PathValue("id"))
+}
+
+// handleRevokeMySessions logs the caller out everywhere, including the device
+// the request came from.
+func (s *Server) handleRevokeMySessions(w http.ResponseWriter, r *http.Request) {
+	s.revokeAllSessions(w, r, authUserFromContext(r.Context()).ID)
+}
+
+func (s *Server) handleListUserSessions(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.parseSessionUserID(w, r)
+	if !ok {
+		return
+	}
+	s.writeSessions(w, r, id, "")
+}
+
+func (s *Server) handleRevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.parseSessionUserID(w, r)
+	if !ok {
+		return
+	}
+	s.revokeOneSession(w, r, id, r.PathValue("sessionId"))
+}
+
+func (s *Server) handleRevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.parseSessionUserID(w, r)
+	if !ok {
+		return
+	}
+	s.revokeAllSessions(w, r, id)
+}
+
+// parseSessionUserID reads the user id of the admin session routes and checks
+// that the account exists, so an unknown user is a 404 rather than an empty
+// list.
+func (s *Server) parseSessionUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, ok := parsePathID(w, r, "invalid user id")
+	if !ok {
+		return 0, false
+	}
+	if _, err := findUserByID(r.Context(), s.db, int64(id)); err != nil {
+		handleUserError(w, err, "failed to get user")
+		return 0, false
+	}
+	return int64(id), true
+}
+
+func (s *Server) writeSessions(w http.ResponseWriter, r *http.Request, userID int64, currentID string) {
+	sessions, err := listSessions(r.Context(), s.db, userID, time.Now())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+
+	items := make([]sessionResponseItem, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, sessionResponseItem{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			SignedInAt: session.SignedInAt.Format(time.RFC3339),
+			LastUsedAt: session.LastUsedAt.Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+			Current:    currentID != "" && session.ID == currentID,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+func (s *Server) revokeOneSession(w http.ResponseWriter, r *http.Request, userID int64, sessionID string) {
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		writeErr(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+	found, err := revokeSession(r.Context(), s.db, userID, sessionID, time.Now())
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+	if !found {
+		writeErr(w, http.StatusNotFound, "session not found")
+		return
+	}
+	s.tokenStates.forget(userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revokeAllSessions signs the user out on every device. Bumping the token
+// version also voids access tokens old enough to carry no session id.
+func (s *Server) revokeAllSessions(w http.ResponseWriter, r *http.Request, userID int64) {
+	if err := revokeSessions(r.Context(), s.db, userID); err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+	s.tokenStates.forget(userID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	smsCodeDigits = 6
+	// smsSendCooldown and smsSendsPerHour throttle sending per phone number;
+	// Config.SMSSendsPerIPPerHour throttles it per client IP.
+	smsSendCooldown = time.Minute
+	smsSendsPerHour = 5
+	// smsMaxAttempts is how many wrong guesses a code survives.
+	smsMaxAttempts = 5
+)
+
+type smsSendRequest struct {
+	Phone string `json:"phone"`
+}
+
+type smsVerifyRequest struct {
+	Phone string `json:"phone"`
+	Code  string `json:"code"`
+	// InviteCode is used when the phone number has no account yet.
+	InviteCode string `json:"inviteCode"`
+}
+
+// handleSendSMSCode texts a login code to a phone number. It works whether
+// or not the number has an account yet; verifying the code registers it.
+func (s *Server) handleSendSMSCode(w http.ResponseWriter, r *http.Request) {
+	var req smsSendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	phone := normalizePhone(req.Phone)
+	if phone == "" {
+		writeErr(w, http.StatusBadRequest, "phone is required")
+		return
+	}
+	if !isValidPhone(phone) {
+		writeErr(w, http.StatusBadRequest, "invalid phone format")
+		return
+	}
+
+	if s.smsIPLimiter != nil {
+		if ok, retryAfter := s.smsIPLimiter.allow(s.clientIP(r), time.Now()); !ok {
+			writeTooManyRequests(w, retryAfter)
+			return
+		}
+	}
+
+	code, err := randomSMSCode()
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to send sms code")
+		return
+	}
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.SMSCodeTTL)
+	retryAfter, err := createSMSCode(r.Context(), s.db, phone, hashSMSCode(phone, code), now, expiresAt)
+	if errors.Is(err, errSMSThrottled) {
+		writeTooManyRequests(w, retryAfter)
+		return
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to send sms code")
+		return
+	}
+	message := fmt.Sprintf("您的登录验证码是 %s，%d 分钟内有效。", code, int(s.cfg.SMSCodeTTL/time.Minute))
+	if err := s.sms.Send(r.Context(), phone, message); err != nil {
+		log.Printf("failed to send sms to %s: %v", phone, err)
+		writeErr(w, http.StatusBadGateway, "failed to send sms code")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"expiresAt":      expiresAt.Format(time.RFC3339),
+		"resendAfterSec": int(smsSendCooldown / time.Second),
+	})
+}
+
+// handleVerifySMSCode signs in with a texted code. A number without an
+// account is registered on the spot, through inviteCode when given, and gets
+// 201; when registrations need approval it gets 201 without tokens.
+func (s *Server) handleVerifySMSCode(w http.ResponseWriter, r *http.Request) {
+	var req smsVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	phone := normalizePhone(req.Phone)
+	code := strings.TrimSpace(req.Code)
+	if phone == "" || code == "" {
+		writeErr(w, http.StatusBadRequest, "phone and code are required")
+		return
+	}
+	if !isValidPhone(phone) {
+		writeErr(w, http.StatusBadRequest, "invalid phone format")
+		return
+	}
+
+	// A new number is checked for a usable invite before the code is spent,
+	// so a missing or mistyped invite does not cost the user their code.
+	user, err := findUserByPhone(r.Context(), s.db, phone)
+	registering := errors.Is(err, sql.ErrNoRows)
+	if registering {
+		err = s.checkRegistration(r.Context(), req.InviteCode, time.Now())
+	}
+	if isInviteError(err) {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to verify sms code")
+		return
+	}
+
+	err = consumeSMSCode(r.Context(), s.db, phone, hashSMSCode(phone, code), smsMaxAttempts, time.Now())
+	if err != nil {
+		if errors.Is(err, errInvalidSMSCode) {
+			writeErr(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		writeErr(w, http.StatusInternalServerError, "failed to verify sms code")
+		return
+	}
+
+	status := http.StatusOK
+	if registering {
+		status = http.StatusCreated
+		user, err = s.registerSMSUser(r, phone, req.InviteCode)
+	}
+	if isInviteError(err) {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to login")
+		return
+	}
+	if status == http.StatusCreated && user.Status == userStatusPending {
+		writeRegistered(w, user)
+		return
+	}
+	if !checkAccountUsable(w, user) {
+		return
+	}
+
+	s.writeTokenPair(w, r, user, status)
+}
+
+// registerSMSUser creates an account for a phone number that signed in by
+// code. The account gets a random password; the user can set a real one
+// through the password reset flow if they ever want to.
+func (s *Server) registerSMSUser(r *http.Request, phone, inviteCode string) (User, error) {
+	password, err := randomHex(24)
+	if err != nil {
+		return User{}, err
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+	user, err := s.registerUser(r.Context(), phone, hash, inviteCode)
+	if errors.Is(err, errDuplicatePhone) {
+		// Registered by a concurrent request in the meantime.
+		return findUserByPhone(r.Context(), s.db, phone)
+	}
+	return user, err
+}
+
+func randomSMSCode() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < smsCodeDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", smsCodeDigits, n), nil
+}
+
+// hashSMSCode binds a code to its phone number so equal codes sent to
+// different numbers never share a hash.
+func hashSMSCode(phone, code string) string {
+	return hashToken(phone + ":" + code)
+}
@@ -3,24 +3,68 @@ package app
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
 
-var errDuplicatePhone = errors.New("phone already exists")
+var (
+	errDuplicatePhone   = errors.New("phone already exists")
+	errLastAdmin        = errors.New("cannot demote or disable the last admin")
+	errInvalidResetCode = errors.New("invalid or expired reset code")
+	errInvalidSMSCode   = errors.New("invalid or expired sms code")
+	errSMSThrottled     = errors.New("too many sms codes requested")
+	// errInvalidInviteCode covers unknown, revoked, expired and used up codes.
+	errInvalidInviteCode  = errors.New("invalid or expired invite code")
+	errInviteCodeRequired = errors.New("inviteCode is required")
+	errNotPending         = errors.New("registration is not pending")
+	// errRefreshTokenInvalid covers unknown, logged out and expired tokens.
+	errRefreshTokenInvalid = errors.New("refresh token is revoked or expired")
+	errRefreshTokenReused  = errors.New("refresh token reuse detected, please sign in again")
+)
 
 type User struct {
 	ID           int64  `json:"id"`
 	Phone        string `json:"phone"`
 	PasswordHash string
 	Role         string `json:"role"`
+	// Status is active, or pending/rejected for registrations awaiting or
+	// refused admin approval.
+	Status     string
+	CreatedAt  time.Time
+	DisabledAt *time.Time
+	// TokenVersion is carried in access tokens as "ver"; bumping it voids
+	// every access token issued before.
+	TokenVersion int64
+	// InviteCodeID is the invite code the user registered with, if any.
+	InviteCodeID *int64
+	// DisplayName, WechatNickname and AvatarURL make up the profile shown to
+	// other users; any of them may be empty.
+	DisplayName    string
+	WechatNickname string
+	AvatarURL      string
+	// Groups lists the chat groups the user belongs to. Only the profile and
+	// listing lookups fill it.
+	Groups []string
 }
 
 func createUser(ctx context.Context, db *sql.DB, phone, passwordHash, role string) (User, error) {
-	res, err := db.ExecContext(ctx, `INSERT INTO users (phone, password_hash, role) VALUES (?, ?, ?)`, phone, passwordHash, role)
+	return insertUser(ctx, db, phone, passwordHash, role, userStatusActive, nil)
+}
+
+func insertUser(ctx context.Context, db execer, phone, passwordHash, role, status string, inviteCodeID *int64) (User, error) {
+	res, err := db.ExecContext(ctx,
+		`INSERT INTO users (phone, password_hash, role, status, invite_code_id) VALUES (?, ?, ?, ?, ?)`,
+		phone,
+		passwordHash,
+		role,
+		status,
+		inviteCodeID,
+	)
 	if err != nil {
 		if strings.Contains(strings.ToLower(err.Error()), "duplicate") {
 			return User{}, errDuplicatePhone
@@ -31,29 +75,368 @@ func createUser(ctx context.Context, db *sql.DB, phone, passwordHash, role strin
 	if err != nil {
 		return User{}, err
 	}
-	return User{ID: id, Phone: phone, PasswordHash: passwordHash, Role: role}, nil
+	return User{ID: id, Phone: phone, PasswordHash: passwordHash, Role: role, Status: status, InviteCodeID: inviteCodeID}, nil
 }
 
-func findUserByPhone(ctx context.Context, db *sql.DB, phone string) (User, error) {
+const userColumns = `id, phone, password_hash, role, status, created_at, disabled_at, token_version, invite_code_id, display_name, wechat_nickname, avatar_url`
+
+func scanUser(scan func(dest ...any) error) (User, error) {
 	user := User{}
-	err := db.QueryRowContext(ctx, `SELECT id, phone, password_hash, role FROM users WHERE phone = ? LIMIT 1`, phone).Scan(
+	var disabledAt sql.NullTime
+	var inviteCodeID sql.NullInt64
+	err := scan(
 		&user.ID,
 		&user.Phone,
 		&user.PasswordHash,
 		&user.Role,
+		&user.Status,
+		&user.CreatedAt,
+		&disabledAt,
+		&user.TokenVersion,
+		&inviteCodeID,
+		&user.DisplayName,
+		&user.WechatNickname,
+		&user.AvatarURL,
 	)
+	if disabledAt.Valid {
+		user.DisabledAt = &disabledAt.Time
+	}
+	if inviteCodeID.Valid {
+		user.InviteCodeID = &inviteCodeID.Int64
+	}
 	return user, err
 }
 
+func findUserByPhone(ctx context.Context, db *sql.DB, phone string) (User, error) {
+	return scanUser(db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE phone = ? LIMIT 1`, phone).Scan)
+}
+
 func findUserByID(ctx context.Context, db *sql.DB, id int64) (User, error) {
-	user := User{}
-	err := db.QueryRowContext(ctx, `SELECT id, phone, password_hash, role FROM users WHERE id = ? LIMIT 1`, id).Scan(
-		&user.ID,
-		&user.Phone,
-		&user.PasswordHash,
-		&user.Role,
+	return scanUser(db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ? LIMIT 1`, id).Scan)
+}
+
+func findTokenState(ctx context.Context, db *sql.DB, userID int64) (tokenState, error) {
+	var state tokenState
+	err := db.QueryRowContext(ctx,
+		`SELECT token_version, disabled_at IS NOT NULL OR status <> 'active' FROM users WHERE id = ? LIMIT 1`,
+		userID,
+	).Scan(&state.Version, &state.Disabled)
+	if err != nil {
+		return tokenState{}, err
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT DISTINCT family_id FROM refresh_tokens WHERE user_id = ? AND revoked_at IS NULL AND expires_at > NOW()`,
+		userID,
 	)
-	return user, err
+	if err != nil {
+		return tokenState{}, err
+	}
+	defer rows.Close()
+	state.Sessions = make(map[string]bool)
+	for rows.Next() {
+		var familyID string
+		if err := rows.Scan(&familyID); err != nil {
+			return tokenState{}, err
+		}
+		state.Sessions[familyID] = true
+	}
+	return state, rows.Err()
+}
+
+// userListFilter narrows the admin user list. Query matches anywhere in the
+// phone number or the names; Disabled picks active or disabled accounts when
+// set.
+type userListFilter struct {
+	Query    string
+	Role     string
+	Status   string
+	Group    string
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
+
+func (f userListFilter) where() (string, []any) {
+	clauses := []string{"1 = 1"}
+	args := []any{}
+	if f.Query != "" {
+		clauses = append(clauses, "(phone LIKE ? OR display_name LIKE ? OR wechat_nickname LIKE ?)")
+		pattern := "%" + escapeLike(f.Query) + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+	if f.Role != "" {
+		clauses = append(clauses, "role = ?")
+		args = append(args, f.Role)
+	}
+	if f.Status != "" {
+		clauses = append(clauses, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.Group != "" {
+		clauses = append(clauses, "id IN (SELECT user_id FROM user_groups WHERE group_name = ?)")
+		args = append(args, f.Group)
+	}
+	if f.Disabled != nil {
+		if *f.Disabled {
+			clauses = append(clauses, "disabled_at IS NOT NULL")
+		} else {
+			clauses = append(clauses, "disabled_at IS NULL")
+		}
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+func listUsers(ctx context.Context, db *sql.DB, filter userListFilter) ([]User, error) {
+	where, args := filter.where()
+	args = append(args, filter.Limit, filter.Offset)
+	rows, err := db.QueryContext(ctx, `SELECT `+userColumns+` FROM users WHERE `+where+` ORDER BY id ASC LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	ids := make([]int64, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	groups, err := loadUserGroups(ctx, db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].Groups = groups[users[i].ID]
+	}
+	return users, nil
+}
+
+// findUserProfile is findUserByID with the user's groups filled in.
+func findUserProfile(ctx context.Context, db *sql.DB, id int64) (User, error) {
+	user, err := findUserByID(ctx, db, id)
+	if err != nil {
+		return User{}, err
+	}
+	groups, err := loadUserGroups(ctx, db, []int64{id})
+	if err != nil {
+		return User{}, err
+	}
+	user.Groups = groups[id]
+	return user, nil
+}
+
+func loadUserGroups(ctx context.Context, db *sql.DB, userIDs []int64) (map[int64][]string, error) {
+	out := make(map[int64][]string)
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+
+	placeholders := make([]string, 0, len(userIDs))
+	args := make([]any, 0, len(userIDs))
+	for _, id := range userIDs {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	rows, err := db.QueryContext(ctx,
+		`SELECT user_id, group_name FROM user_groups WHERE user_id IN (`+strings.Join(placeholders, ", ")+`) ORDER BY group_name ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID int64
+		var name string
+		if err := rows.Scan(&userID, &name); err != nil {
+			return nil, err
+		}
+		out[userID] = append(out[userID], name)
+	}
+	return out, rows.Err()
+}
+
+// profileUpdate carries the profile fields to change; nil fields are kept.
+// Groups replaces the whole group list when set.
+type profileUpdate struct {
+	DisplayName    *string
+	WechatNickname *string
+	AvatarURL      *string
+	Groups         *[]string
+}
+
+func (p profileUpdate) empty() bool {
+	return p.DisplayName == nil && p.WechatNickname == nil && p.AvatarURL == nil && p.Groups == nil
+}
+
+func updateUserProfile(ctx context.Context, db *sql.DB, id int64, update profileUpdate) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int64
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = ? FOR UPDATE`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if err := applyUserProfile(ctx, tx, id, update); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyUserProfile writes the fields set in update inside the caller's
+// transaction.
+func applyUserProfile(ctx context.Context, tx execer, id int64, update profileUpdate) error {
+	sets := []string{}
+	args := []any{}
+	if update.DisplayName != nil {
+		sets = append(sets, "display_name = ?")
+		args = append(args, *update.DisplayName)
+	}
+	if update.WechatNickname != nil {
+		sets = append(sets, "wechat_nickname = ?")
+		args = append(args, *update.WechatNickname)
+	}
+	if update.AvatarURL != nil {
+		sets = append(sets, "avatar_url = ?")
+		args = append(args, *update.AvatarURL)
+	}
+	if len(sets) > 0 {
+		args = append(args, id)
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET `+strings.Join(sets, ", ")+` WHERE id = ?`, args...); err != nil {
+			return err
+		}
+	}
+	if update.Groups != nil {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM user_groups WHERE user_id = ?`, id); err != nil {
+			return err
+		}
+		for _, name := range *update.Groups {
+			if err := addUserGroup(ctx, tx, id, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func addUserGroup(ctx context.Context, db execer, userID int64, name string) error {
+	_, err := db.ExecContext(ctx, `INSERT IGNORE INTO user_groups (user_id, group_name) VALUES (?, ?)`, userID, name)
+	return err
+}
+
+func countUsers(ctx context.Context, db *sql.DB, filter userListFilter) (int64, error) {
+	where, args := filter.where()
+	var n int64
+	err := db.QueryRowContext(ctx, `SELECT COUNT(1) FROM users WHERE `+where, args...).Scan(&n)
+	return n, err
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// userUpdate is an admin edit of an account; nil fields are left alone.
+type userUpdate struct {
+	Role     *string
+	Disabled *bool
+	Profile  profileUpdate
+}
+
+// updateUser applies an admin edit in one transaction, so a change that
+// fails, such as removing the last active admin, leaves the account as it
+// was. Changing the role or disabling the account voids its access tokens;
+// disabling also revokes its refresh tokens.
+func updateUser(ctx context.Context, db *sql.DB, id int64, update userUpdate) error {
+	return withLastAdminGuard(ctx, db, id, func(tx *sql.Tx, user User) (bool, error) {
+		removesAdmin := false
+		if update.Role != nil && *update.Role != user.Role {
+			if _, err := tx.ExecContext(ctx, `UPDATE users SET role = ?, token_version = token_version + 1 WHERE id = ?`, *update.Role, id); err != nil {
+				return false, err
+			}
+			removesAdmin = user.Role == "admin"
+		}
+		if update.Disabled != nil {
+			removes, err := applyUserDisabled(ctx, tx, user, *update.Disabled)
+			if err != nil {
+				return false, err
+			}
+			removesAdmin = removesAdmin || removes
+		}
+		return removesAdmin, applyUserProfile(ctx, tx, id, update.Profile)
+	})
+}
+
+// applyUserDisabled disables or re-enables user and reports whether that
+// took an active admin away.
+func applyUserDisabled(ctx context.Context, tx *sql.Tx, user User, disabled bool) (bool, error) {
+	if !disabled {
+		_, err := tx.ExecContext(ctx, `UPDATE users SET disabled_at = NULL WHERE id = ?`, user.ID)
+		return false, err
+	}
+	if user.DisabledAt != nil {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET disabled_at = NOW(), token_version = token_version + 1 WHERE id = ?`, user.ID); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = ? AND revoked_at IS NULL`, user.ID); err != nil {
+		return false, err
+	}
+	return user.Role == "admin", nil
+}
+
+// withLastAdminGuard runs change for user id in a transaction that holds the
+// rows of all active admins, so two concurrent demotions cannot both pass the
+// check. change reports whether it removed an active admin; the transaction
+// is rolled back with errLastAdmin when none would be left.
+func withLastAdminGuard(ctx context.Context, db *sql.DB, id int64, change func(tx *sql.Tx, user User) (bool, error)) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM users WHERE role = 'admin' AND disabled_at IS NULL FOR UPDATE`)
+	if err != nil {
+		return err
+	}
+	activeAdmins := 0
+	for rows.Next() {
+		activeAdmins++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = ? FOR UPDATE`, id).Scan)
+	if err != nil {
+		return err
+	}
+	removesAdmin, err := change(tx, user)
+	if err != nil {
+		return err
+	}
+	if removesAdmin && user.DisabledAt == nil && activeAdmins <= 1 {
+		return errLastAdmin
+	}
+	return tx.Commit()
 }
 
 func countAdmins(ctx context.Context, db *sql.DB) (int, error) {
@@ -67,31 +450,115 @@ func hashToken(token string) string {
 	return hex.EncodeToString(sum[:])
 }
 
-func storeRefreshToken(ctx context.Context, db *sql.DB, userID int64, refreshToken string, expiresAt time.Time) error {
-	tokenHash := hashToken(refreshToken)
+// refreshTokenRecord is one issued refresh token. Tokens rotated from the
+// same login share a FamilyID, which is also the session id users see.
+type refreshTokenRecord struct {
+	UserID    int64
+	Token     string
+	JTI       string
+	FamilyID  string
+	UserAgent string
+	IP        string
+	// IssuedAt seeds last_used_at, which moves on each time the token is
+	// presented.
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func storeRefreshToken(ctx context.Context, db execer, record refreshTokenRecord) error {
 	_, err := db.ExecContext(ctx,
-		`INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES (?, ?, ?)`,
-		userID,
-		tokenHash,
-		expiresAt,
+		`INSERT INTO refresh_tokens (user_id, token_hash, jti, family_id, user_agent, ip, last_used_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.UserID,
+		hashToken(record.Token),
+		record.JTI,
+		record.FamilyID,
+		truncateRunes(record.UserAgent, maxUserAgentLen),
+		record.IP,
+		record.IssuedAt,
+		record.ExpiresAt,
 	)
 	return err
 }
 
-func isRefreshTokenActive(ctx context.Context, db *sql.DB, refreshToken string) (bool, error) {
-	tokenHash := hashToken(refreshToken)
-	var exists int
-	err := db.QueryRowContext(ctx,
-		`SELECT 1 FROM refresh_tokens WHERE token_hash = ? AND revoked_at IS NULL AND expires_at > NOW() LIMIT 1`,
-		tokenHash,
-	).Scan(&exists)
+const maxUserAgentLen = 255
+
+func truncateRunes(value string, n int) string {
+	runes := []rune(value)
+	if len(runes) <= n {
+		return value
+	}
+	return string(runes[:n])
+}
+
+func findRefreshTokenFamily(ctx context.Context, db *sql.DB, refreshToken string) (string, error) {
+	var familyID string
+	err := db.QueryRowContext(ctx, `SELECT family_id FROM refresh_tokens WHERE token_hash = ? LIMIT 1`, hashToken(refreshToken)).Scan(&familyID)
+	return familyID, err
+}
+
+// rotateRefreshToken swaps oldToken for next in one transaction; next must
+// belong to the family of oldToken. The old row stays locked from the check to the
+// revoke, so of two concurrent refreshes with the same token only one
+// succeeds. Presenting a token that was already rotated means it leaked: the
+// whole family is revoked, a security event is recorded and
+// errRefreshTokenReused returned.
+func rotateRefreshToken(ctx context.Context, db *sql.DB, oldToken string, next refreshTokenRecord, ip string, now time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var (
+		id        int64
+		userID    int64
+		familyID  string
+		expiresAt time.Time
+		revokedAt sql.NullTime
+		rotatedAt sql.NullTime
+	)
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, user_id, family_id, expires_at, revoked_at, rotated_at FROM refresh_tokens WHERE token_hash = ? LIMIT 1 FOR UPDATE`,
+		hashToken(oldToken),
+	).Scan(&id, &userID, &familyID, &expiresAt, &revokedAt, &rotatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
-		return false, nil
+		return errRefreshTokenInvalid
 	}
 	if err != nil {
-		return false, err
+		return err
+	}
+	if userID != next.UserID || familyID != next.FamilyID {
+		return errRefreshTokenInvalid
+	}
+
+	if rotatedAt.Valid {
+		if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`, now, familyID); err != nil {
+			return err
+		}
+		detail := fmt.Sprintf("rotated refresh token presented again; family %s revoked", familyID)
+		if err := recordSecurityEvent(ctx, tx, userID, securityEventRefreshReuse, detail, ip); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return errRefreshTokenReused
+	}
+	if revokedAt.Valid || !expiresAt.After(now) {
+		return errRefreshTokenInvalid
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = ?, rotated_at = ?, last_used_at = ? WHERE id = ?`, now, now, now, id); err != nil {
+		return err
 	}
-	return true, nil
+	if err := storeRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func revokeRefreshToken(ctx context.Context, db *sql.DB, refreshToken string) error {
@@ -99,3 +566,724 @@ func revokeRefreshToken(ctx context.Context, db *sql.DB, refreshToken string) er
 	_, err := db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE token_hash = ? AND revoked_at IS NULL`, tokenHash)
 	return err
 }
+
+// changeUserPassword sets a new password, voids the access tokens issued
+// under the old one and signs the user out everywhere except the session
+// holding keepToken, all in one transaction.
+func changeUserPassword(ctx context.Context, db *sql.DB, userID int64, passwordHash, keepToken string) error {
+	keepHash := ""
+	if keepToken != "" {
+		keepHash = hashToken(keepToken)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = ?, token_version = token_version + 1 WHERE id = ?`, passwordHash, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = ? AND revoked_at IS NULL AND token_hash <> ?`,
+		userID,
+		keepHash,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// revokeSessions signs the user out everywhere: it revokes every refresh
+// token and voids the access tokens issued so far, including those of tokens
+// that carry no session id.
+func revokeSessions(ctx context.Context, db *sql.DB, userID int64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET token_version = token_version + 1 WHERE id = ?`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = ? AND revoked_at IS NULL`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// createPasswordResetCode stores a new reset code for the user, replacing any
+// earlier code that was not used yet.
+func createPasswordResetCode(ctx context.Context, db *sql.DB, userID, createdBy int64, code string, expiresAt time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM password_reset_codes WHERE user_id = ? AND used_at IS NULL`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO password_reset_codes (user_id, code_hash, expires_at, created_by) VALUES (?, ?, ?, ?)`,
+		userID,
+		hashToken(code),
+		expiresAt,
+		createdBy,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// resetPasswordWithCode spends a reset code and sets the new password in one
+// transaction, so a code works exactly once. All sessions of the user are
+// revoked. It fails with errInvalidResetCode when the code is unknown, used
+// or expired.
+func resetPasswordWithCode(ctx context.Context, db *sql.DB, userID int64, code, passwordHash string, now time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var codeID int64
+	err = tx.QueryRowContext(ctx,
+		`SELECT id FROM password_reset_codes WHERE user_id = ? AND code_hash = ? AND used_at IS NULL AND expires_at > ? LIMIT 1 FOR UPDATE`,
+		userID,
+		hashToken(code),
+		now,
+	).Scan(&codeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errInvalidResetCode
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE password_reset_codes SET used_at = ? WHERE id = ?`, now, codeID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET password_hash = ?, token_version = token_version + 1 WHERE id = ?`, passwordHash, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = ? AND revoked_at IS NULL`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// createSMSCode stores the hash of a new login code for phone unless the
+// number is throttled: a code was sent within smsSendCooldown, or
+// smsSendsPerHour in the last hour. The check reads the number's codes with
+// a locking read in the same transaction as the insert, so concurrent
+// requests cannot all pass it; a request that loses that race is throttled
+// too. Throttled sends fail with errSMSThrottled and how long to wait.
+// Earlier codes that were not used are closed so only the latest one is
+// accepted.
+func createSMSCode(ctx context.Context, db *sql.DB, phone, codeHash string, sentAt, expiresAt time.Time) (time.Duration, error) {
+	retryAfter, err := insertSMSCode(ctx, db, phone, codeHash, sentAt, expiresAt)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "deadlock") {
+		return smsSendCooldown, errSMSThrottled
+	}
+	return retryAfter, err
+}
+
+func insertSMSCode(ctx context.Context, db *sql.DB, phone, codeHash string, sentAt, expiresAt time.Time) (time.Duration, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var n int
+	var first, last sql.NullTime
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(1), MIN(sent_at), MAX(sent_at) FROM sms_codes WHERE phone = ? AND sent_at > ? FOR UPDATE`,
+		phone,
+		sentAt.Add(-time.Hour),
+	).Scan(&n, &first, &last); err != nil {
+		return 0, err
+	}
+	if n > 0 && sentAt.Sub(last.Time) < smsSendCooldown {
+		return smsSendCooldown - sentAt.Sub(last.Time), errSMSThrottled
+	}
+	if n >= smsSendsPerHour {
+		return first.Time.Add(time.Hour).Sub(sentAt), errSMSThrottled
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE sms_codes SET consumed_at = ? WHERE phone = ? AND consumed_at IS NULL`, sentAt, phone); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO sms_codes (phone, code_hash, sent_at, expires_at) VALUES (?, ?, ?, ?)`,
+		phone,
+		codeHash,
+		sentAt,
+		expiresAt,
+	); err != nil {
+		return 0, err
+	}
+	return 0, tx.Commit()
+}
+
+// consumeSMSCode checks codeHash against the open code of phone. A wrong
+// guess counts as an attempt and the code is closed once maxAttempts is
+// reached; a match closes it for good. It fails with errInvalidSMSCode.
+func consumeSMSCode(ctx context.Context, db *sql.DB, phone, codeHash string, maxAttempts int, now time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var (
+		id       int64
+		stored   string
+		attempts int
+	)
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, code_hash, attempts FROM sms_codes WHERE phone = ? AND consumed_at IS NULL AND expires_at > ? ORDER BY id DESC LIMIT 1 FOR UPDATE`,
+		phone,
+		now,
+	).Scan(&id, &stored, &attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errInvalidSMSCode
+	}
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(codeHash)) == 1 {
+		if _, err := tx.ExecContext(ctx, `UPDATE sms_codes SET consumed_at = ? WHERE id = ?`, now, id); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	attempts++
+	if attempts >= maxAttempts {
+		_, err = tx.ExecContext(ctx, `UPDATE sms_codes SET attempts = ?, consumed_at = ? WHERE id = ?`, attempts, now, id)
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE sms_codes SET attempts = ? WHERE id = ?`, attempts, id)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return errInvalidSMSCode
+}
+
+// loginAttempt is the failure counter of one phone number or client IP.
+type loginAttempt struct {
+	Scope         string
+	Subject       string
+	Failures      int
+	Lockouts      int
+	WindowStart   time.Time
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// loginLockedUntil returns the latest lockout among the given counters that
+// is still running at now, if any.
+func loginLockedUntil(ctx context.Context, db *sql.DB, phone, ip string, now time.Time) (time.Time, bool, error) {
+	var until sql.NullTime
+	err := db.QueryRowContext(ctx,
+		`SELECT MAX(locked_until) FROM login_attempts WHERE ((scope = 'phone' AND subject = ?) OR (scope = 'ip' AND subject = ?)) AND locked_until > ?`,
+		phone,
+		ip,
+		now,
+	).Scan(&until)
+	return until.Time, until.Valid, err
+}
+
+// loginLockoutMemory is how long earlier lockouts count towards doubling the
+// next one.
+const loginLockoutMemory = 24 * time.Hour
+
+// recordLoginFailure counts a failed login against scope/subject. Failures
+// older than window start a new count. Reaching threshold locks the subject
+// out for lockout, doubled for every lockout before it (up to 64x), and
+// starts the count over.
+func recordLoginFailure(ctx context.Context, db *sql.DB, scope, subject string, threshold int, window, lockout time.Duration, now time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT IGNORE INTO login_attempts (scope, subject, window_start, last_failure_at) VALUES (?, ?, ?, ?)`,
+		scope, subject, now, now,
+	); err != nil {
+		return err
+	}
+	var a loginAttempt
+	if err := tx.QueryRowContext(ctx,
+		`SELECT failures, lockouts, window_start, last_failure_at FROM login_attempts WHERE scope = ? AND subject = ? FOR UPDATE`,
+		scope, subject,
+	).Scan(&a.Failures, &a.Lockouts, &a.WindowStart, &a.LastFailureAt); err != nil {
+		return err
+	}
+
+	// A quiet day forgives earlier lockouts.
+	if now.Sub(a.LastFailureAt) > loginLockoutMemory {
+		a.Lockouts = 0
+	}
+	if now.Sub(a.WindowStart) > window {
+		a.Failures = 0
+		a.WindowStart = now
+	}
+	a.Failures++
+
+	var lockedUntil *time.Time
+	if a.Failures >= threshold {
+		until := now.Add(lockout << min(a.Lockouts, 6))
+		lockedUntil = &until
+		a.Lockouts++
+		a.Failures = 0
+		a.WindowStart = now
+	}
+
+	if lockedUntil != nil {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE login_attempts SET failures = ?, lockouts = ?, window_start = ?, last_failure_at = ?, locked_until = ? WHERE scope = ? AND subject = ?`,
+			a.Failures, a.Lockouts, a.WindowStart, now, *lockedUntil, scope, subject,
+		)
+	} else {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE login_attempts SET failures = ?, lockouts = ?, window_start = ?, last_failure_at = ? WHERE scope = ? AND subject = ?`,
+			a.Failures, a.Lockouts, a.WindowStart, now, scope, subject,
+		)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// clearLoginAttempts forgets the counter of scope/subject and reports whether
+// there was one.
+func clearLoginAttempts(ctx context.Context, db *sql.DB, scope, subject string) (bool, error) {
+	res, err := db.ExecContext(ctx, `DELETE FROM login_attempts WHERE scope = ? AND subject = ?`, scope, subject)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// listLoginLockouts returns the counters that are locked out at now.
+func listLoginLockouts(ctx context.Context, db *sql.DB, now time.Time) ([]loginAttempt, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT scope, subject, failures, lockouts, window_start, last_failure_at, locked_until FROM login_attempts WHERE locked_until > ? ORDER BY locked_until DESC`,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]loginAttempt, 0)
+	for rows.Next() {
+		var a loginAttempt
+		var lockedUntil sql.NullTime
+		if err := rows.Scan(&a.Scope, &a.Subject, &a.Failures, &a.Lockouts, &a.WindowStart, &a.LastFailureAt, &lockedUntil); err != nil {
+			return nil, err
+		}
+		if lockedUntil.Valid {
+			a.LockedUntil = &lockedUntil.Time
+		}
+		items = append(items, a)
+	}
+	return items, rows.Err()
+}
+
+// Security event types.
+const (
+	securityEventRefreshReuse = "refresh_token_reuse"
+)
+
+type securityEvent struct {
+	ID        int64
+	UserID    *int64
+	EventType string
+	Detail    string
+	IP        string
+	CreatedAt time.Time
+}
+
+func recordSecurityEvent(ctx context.Context, db execer, userID int64, eventType, detail, ip string) error {
+	var user *int64
+	if userID != 0 {
+		user = &userID
+	}
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO security_events (user_id, event_type, detail, ip) VALUES (?, ?, ?, ?)`,
+		user,
+		eventType,
+		detail,
+		ip,
+	)
+	return err
+}
+
+func listSecurityEvents(ctx context.Context, db *sql.DB, limit, offset int) ([]securityEvent, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, user_id, event_type, detail, ip, created_at FROM security_events ORDER BY id DESC LIMIT ? OFFSET ?`,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]securityEvent, 0)
+	for rows.Next() {
+		var e securityEvent
+		var userID sql.NullInt64
+		if err := rows.Scan(&e.ID, &userID, &e.EventType, &e.Detail, &e.IP, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if userID.Valid {
+			e.UserID = &userID.Int64
+		}
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
+
+func countSecurityEvents(ctx context.Context, db *sql.DB) (int64, error) {
+	var n int64
+	err := db.QueryRowContext(ctx, `SELECT COUNT(1) FROM security_events`).Scan(&n)
+	return n, err
+}
+
+// session is one signed-in device: a refresh token family with a live token.
+type session struct {
+	ID         string
+	UserAgent  string
+	IP         string
+	SignedInAt time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+func listSessions(ctx context.Context, db *sql.DB, userID int64, now time.Time) ([]session, error) {
+	rows, err := db.QueryContext(ctx, `
+SELECT t.family_id, t.user_agent, t.ip, t.last_used_at, t.expires_at,
+  (SELECT MIN(f.last_used_at) FROM refresh_tokens f WHERE f.family_id = t.family_id) AS signed_in_at
+FROM refresh_tokens t
+WHERE t.user_id = ? AND t.revoked_at IS NULL AND t.expires_at > ?
+ORDER BY t.last_used_at DESC, t.id DESC`,
+		userID,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]session, 0)
+	for rows.Next() {
+		var item session
+		if err := rows.Scan(&item.ID, &item.UserAgent, &item.IP, &item.LastUsedAt, &item.ExpiresAt, &item.SignedInAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// revokeSession signs one device out and reports whether the user had a live
+// session with that id.
+func revokeSession(ctx context.Context, db *sql.DB, userID int64, sessionID string, now time.Time) (bool, error) {
+	res, err := db.ExecContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND family_id = ? AND revoked_at IS NULL AND expires_at > ?`,
+		now,
+		userID,
+		sessionID,
+		now,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// inviteCode lets people register. MaxUses and ExpiresAt are optional; when
+// Group is set, people registering with the code join that group.
+type inviteCode struct {
+	ID        int64
+	Code      string
+	Group     string
+	Role      string
+	MaxUses   *int
+	UsedCount int
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+	CreatedBy int64
+	CreatedAt time.Time
+}
+
+const inviteCodeColumns = `id, code, group_name, role, max_uses, used_count, expires_at, revoked_at, created_by, created_at`
+
+func scanInviteCode(scan func(dest ...any) error) (inviteCode, error) {
+	var code inviteCode
+	var maxUses sql.NullInt64
+	var expiresAt, revokedAt sql.NullTime
+	err := scan(
+		&code.ID,
+		&code.Code,
+		&code.Group,
+		&code.Role,
+		&maxUses,
+		&code.UsedCount,
+		&expiresAt,
+		&revokedAt,
+		&code.CreatedBy,
+		&code.CreatedAt,
+	)
+	if maxUses.Valid {
+		n := int(maxUses.Int64)
+		code.MaxUses = &n
+	}
+	if expiresAt.Valid {
+		code.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		code.RevokedAt = &revokedAt.Time
+	}
+	return code, err
+}
+
+// usable reports whether the code can still register someone at now.
+func (c inviteCode) usable(now time.Time) bool {
+	if c.RevokedAt != nil {
+		return false
+	}
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return false
+	}
+	return c.MaxUses == nil || c.UsedCount < *c.MaxUses
+}
+
+func createInviteCode(ctx context.Context, db *sql.DB, code inviteCode) (inviteCode, error) {
+	res, err := db.ExecContext(ctx,
+		`INSERT INTO invite_codes (code, group_name, role, max_uses, expires_at, created_by) VALUES (?, ?, ?, ?, ?, ?)`,
+		code.Code,
+		code.Group,
+		code.Role,
+		code.MaxUses,
+		code.ExpiresAt,
+		code.CreatedBy,
+	)
+	if err != nil {
+		return inviteCode{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return inviteCode{}, err
+	}
+	return findInviteCodeByID(ctx, db, id)
+}
+
+func findInviteCodeByID(ctx context.Context, db *sql.DB, id int64) (inviteCode, error) {
+	return scanInviteCode(db.QueryRowContext(ctx, `SELECT `+inviteCodeColumns+` FROM invite_codes WHERE id = ? LIMIT 1`, id).Scan)
+}
+
+func findInviteCodeByCode(ctx context.Context, db *sql.DB, code string) (inviteCode, error) {
+	return scanInviteCode(db.QueryRowContext(ctx, `SELECT `+inviteCodeColumns+` FROM invite_codes WHERE code = ? LIMIT 1`, code).Scan)
+}
+
+func listInviteCodes(ctx context.Context, db *sql.DB, limit, offset int) ([]inviteCode, error) {
+	rows, err := db.QueryContext(ctx, `SELECT `+inviteCodeColumns+` FROM invite_codes ORDER BY id DESC LIMIT ? OFFSET ?`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := make([]inviteCode, 0)
+	for rows.Next() {
+		code, err := scanInviteCode(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
+
+func countInviteCodes(ctx context.Context, db *sql.DB) (int64, error) {
+	var n int64
+	err := db.QueryRowContext(ctx, `SELECT COUNT(1) FROM invite_codes`).Scan(&n)
+	return n, err
+}
+
+// revokeInviteCode stops a code from registering anyone else. Revoking a
+// code twice is not an error.
+func revokeInviteCode(ctx context.Context, db *sql.DB, id int64, now time.Time) error {
+	res, err := db.ExecContext(ctx, `UPDATE invite_codes SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?`, now, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := findInviteCodeByID(ctx, db, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createUserWithInvite registers a user with the role and group of an invite
+// code and counts the use in one transaction, so a code is never used more often than
+// it allows. It fails with errInvalidInviteCode when the code cannot be used.
+func createUserWithInvite(ctx context.Context, db *sql.DB, phone, passwordHash, code string, now time.Time) (User, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, err
+	}
+	defer tx.Rollback()
+
+	invite, err := scanInviteCode(tx.QueryRowContext(ctx, `SELECT `+inviteCodeColumns+` FROM invite_codes WHERE code = ? LIMIT 1 FOR UPDATE`, code).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, errInvalidInviteCode
+	}
+	if err != nil {
+		return User{}, err
+	}
+	if !invite.usable(now) {
+		return User{}, errInvalidInviteCode
+	}
+
+	user, err := insertUser(ctx, tx, phone, passwordHash, invite.Role, userStatusActive, &invite.ID)
+	if err != nil {
+		return User{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE invite_codes SET used_count = used_count + 1 WHERE id = ?`, invite.ID); err != nil {
+		return User{}, err
+	}
+	if invite.Group != "" {
+		if err := addUserGroup(ctx, tx, user.ID, invite.Group); err != nil {
+			return User{}, err
+		}
+		user.Groups = []string{invite.Group}
+	}
+	if err := tx.Commit(); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// reviewRegistration moves a registration to status when it is currently in
+// one of from, recording who decided. It fails with errNotPending when the
+// account is in another state and sql.ErrNoRows when there is no such user.
+func reviewRegistration(ctx context.Context, db *sql.DB, id int64, from []string, status string, reviewer int64, now time.Time) error {
+	args := []any{status, reviewer, now, id}
+	placeholders := make([]string, 0, len(from))
+	for _, s := range from {
+		placeholders = append(placeholders, "?")
+		args = append(args, s)
+	}
+	res, err := db.ExecContext(ctx,
+		`UPDATE users SET status = ?, reviewed_by = ?, reviewed_at = ? WHERE id = ? AND status IN (`+strings.Join(placeholders, ", ")+`)`,
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if _, err := findUserByID(ctx, db, id); err != nil {
+			return err
+		}
+		return errNotPending
+	}
+	return nil
+}
+
+// purgeBatchSize caps each DELETE of a purge so one run never holds locks on
+// a large part of a table.
+const purgeBatchSize = 1000
+
+// purgeInBatches runs a DELETE ending in LIMIT ? until it removes fewer rows
+// than a full batch, and returns the total removed.
+func purgeInBatches(ctx context.Context, db *sql.DB, query string, args ...any) (int64, error) {
+	args = append(args, purgeBatchSize)
+	var total int64
+	for {
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// purgeRefreshTokens deletes refresh tokens that expired or were revoked
+// before cutoff. Tokens of live sessions are never touched.
+func purgeRefreshTokens(ctx context.Context, db *sql.DB, cutoff time.Time) (int64, error) {
+	return purgeInBatches(ctx, db,
+		`DELETE FROM refresh_tokens WHERE expires_at < ? OR revoked_at < ? LIMIT ?`,
+		cutoff,
+		cutoff,
+	)
+}
+
+// purgeIdempotencyKeys deletes ledger idempotency keys older than ttl. A
+// retry arriving after that books the entry again, so ttl must comfortably
+// outlast how long clients keep retrying.
+func purgeIdempotencyKeys(ctx context.Context, db *sql.DB, ttl time.Duration) (int64, error) {
+	return purgeInBatches(ctx, db,
+		`DELETE FROM ledger_idempotency_keys WHERE created_at < NOW() - INTERVAL ? SECOND LIMIT ?`,
+		int64(ttl/time.Second),
+	)
+}
+
+// purgeLoginAttempts deletes the failure counters of subjects that are not
+// locked out and last failed before cutoff, which must be at least a
+// failure window and loginLockoutMemory ago so no count is lost.
+func purgeLoginAttempts(ctx context.Context, db *sql.DB, cutoff, now time.Time) (int64, error) {
+	return purgeInBatches(ctx, db,
+		`DELETE FROM login_attempts WHERE last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?) LIMIT ?`,
+		cutoff,
+		now,
+	)
+}
+
+// purgeSecurityEvents deletes security events recorded before cutoff.
+func purgeSecurityEvents(ctx context.Context, db *sql.DB, cutoff time.Time) (int64, error) {
+	return purgeInBatches(ctx, db, `DELETE FROM security_events WHERE created_at < ? LIMIT ?`, cutoff)
+}
+
+// purgeOneTimeCodes deletes SMS login codes and password reset codes that
+// expired before cutoff.
+func purgeOneTimeCodes(ctx context.Context, db *sql.DB, cutoff time.Time) (int64, error) {
+	sms, err := purgeInBatches(ctx, db, `DELETE FROM sms_codes WHERE expires_at < ? LIMIT ?`, cutoff)
+	if err != nil {
+		return sms, err
+	}
+	reset, err := purgeInBatches(ctx, db, `DELETE FROM password_reset_codes WHERE expires_at < ? LIMIT ?`, cutoff)
+	return sms + reset, err
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxTokenStateEntries bounds the cache; expired entries are swept once the
+// map grows past it.
+const maxTokenStateEntries = 10000
+
+// tokenState is what withAuth checks an access token against: tokens minted
+// before the last bump of the user's token version are no longer accepted,
+// nor are tokens of a session that was signed out. Sessions holds the refresh
+// token families of the user that are still live.
+type tokenState struct {
+	Version  int64
+	Disabled bool
+	Sessions map[string]bool
+}
+
+// tokenStateCache keeps the token state of recently seen users for ttl so an
+// authenticated request costs at most one primary-key lookup per user per
+// ttl. Changes made by this process call forget, which makes them effective
+// at once; ttl only bounds how long edits made elsewhere take to apply.
+type tokenStateCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	load    func(ctx context.Context, userID int64) (tokenState, error)
+	entries map[int64]tokenStateEntry
+}
+
+type tokenStateEntry struct {
+	state    tokenState
+	loadedAt time.Time
+	// loaded is false once the entry is forgotten or due for a reload.
+	loaded bool
+	// generation counts forgets of the user, so a load that raced with one
+	// is not cached.
+	generation uint64
+	// sessionReloadAt is when a token of a session missing from state last
+	// forced a reload.
+	sessionReloadAt time.Time
+}
+
+func newTokenStateCache(ttl time.Duration, load func(ctx context.Context, userID int64) (tokenState, error)) *tokenStateCache {
+	return &tokenStateCache{ttl: ttl, load: load, entries: make(map[int64]tokenStateEntry)}
+}
+
+func (c *tokenStateCache) get(ctx context.Context, userID int64, now time.Time) (tokenState, error) {
+	state, _, err := c.lookup(ctx, userID, now)
+	return state, err
+}
+
+// lookup is get that also reports whether the state came from the cache.
+func (c *tokenStateCache) lookup(ctx context.Context, userID int64, now time.Time) (tokenState, bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	c.mu.Unlock()
+	if ok && entry.loaded && now.Sub(entry.loadedAt) < c.ttl {
+		return entry.state, true, nil
+	}
+
+	state, err := c.load(ctx, userID)
+	if err != nil {
+		return tokenState{}, false, err
+	}
+	if c.ttl <= 0 {
+		return state, false, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current, exists := c.entries[userID]
+	if exists && current.generation != entry.generation {
+		return state, false, nil
+	}
+	if !exists && len(c.entries) >= maxTokenStateEntries {
+		c.sweep(now)
+	}
+	if exists || len(c.entries) < maxTokenStateEntries {
+		c.entries[userID] = tokenStateEntry{
+			state:           state,
+			loadedAt:        now,
+			loaded:          true,
+			generation:      current.generation,
+			sessionReloadAt: current.sessionReloadAt,
+		}
+	}
+	return state, false, nil
+}
+
+// getSession is get for an access token of session sessionID. A session
+// missing from a cached state may have started after it was loaded, so the
+// state is loaded again, at most once per ttl so that replaying a signed-out
+// token does not cost a lookup per request. Sessions started by this process
+// forget the user and never depend on that reload.
+func (c *tokenStateCache) getSession(ctx context.Context, userID int64, sessionID string, now time.Time) (tokenState, error) {
+	state, cached, err := c.lookup(ctx, userID, now)
+	if err != nil || !cached || sessionID == "" || state.Sessions[sessionID] {
+		return state, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	if !ok || now.Sub(entry.sessionReloadAt) < c.ttl {
+		c.mu.Unlock()
+		return state, nil
+	}
+	entry.loaded = false
+	entry.sessionReloadAt = now
+	c.entries[userID] = entry
+	c.mu.Unlock()
+	return c.get(ctx, userID, now)
+}
+
+// forget drops the cached state of a user whose role, status, password or
+// sessions just changed. The entry stays behind, unloaded, for ttl so a load
+// that started before the change is not cached.
+func (c *tokenStateCache) forget(userID int64) {
+	if c.ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[userID]
+	if !ok && len(c.entries) >= maxTokenStateEntries {
+		c.sweep(now)
+	}
+	c.entries[userID] = tokenStateEntry{
+		loadedAt:        now,
+		generation:      entry.generation + 1,
+		sessionReloadAt: entry.sessionReloadAt,
+	}
+}
+
+func (c *tokenStateCache) sweep(now time.Time) {
+	for userID, entry := range c.entries {
+		if now.Sub(entry.loadedAt) >= c.ttl {
+			delete(c.entries, userID)
+		}
+	}
+}
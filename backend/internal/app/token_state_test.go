@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenStateCache(t *testing.T) {
+	loads := 0
+	version := int64(1)
+	cache := newTokenStateCache(time.Minute, func(ctx context.Context, userID int64) (tokenState, error) {
+		loads++
+		return tokenState{Version: version}, nil
+	})
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if state, _ := cache.get(ctx, 7, now); state.Version != 1 {
+			t.Fatalf("version = %d, want 1", state.Version)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1 within the ttl", loads)
+	}
+
+	version = 2
+	cache.forget(7)
+	if state, _ := cache.get(ctx, 7, now); state.Version != 2 {
+		t.Errorf("version after forget = %d, want 2", state.Version)
+	}
+
+	version = 3
+	if state, _ := cache.get(ctx, 7, now.Add(time.Minute)); state.Version != 3 {
+		t.Errorf("version after ttl = %d, want 3", state.Version)
+	}
+	if loads != 3 {
+		t.Errorf("loads = %d, want 3", loads)
+	}
+}
+
+func TestTokenStateCacheIsBounded(t *testing.T) {
+	cache := newTokenStateCache(time.Minute, func(ctx context.Context, userID int64) (tokenState, error) {
+		return tokenState{}, nil
+	})
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for id := int64(0); id < maxTokenStateEntries+100; id++ {
+		cache.get(context.Background(), id, now)
+	}
+	if n := len(cache.entries); n > maxTokenStateEntries {
+		t.Errorf("cache holds %d entries, want at most %d", n, maxTokenStateEntries)
+	}
+
+	// Once the old entries expire they make room for new users.
+	cache.get(context.Background(), -1, now.Add(time.Minute))
+	if _, ok := cache.entries[-1]; !ok {
+		t.Error("expected the new entry to be cached after a sweep")
+	}
+}
+
+func TestTokenStateCacheSession(t *testing.T) {
+	loads := 0
+	sessions := map[string]bool{"a": true}
+	cache := newTokenStateCache(time.Minute, func(ctx context.Context, userID int64) (tokenState, error) {
+		loads++
+		live := make(map[string]bool)
+		for id := range sessions {
+			live[id] = true
+		}
+		return tokenState{Sessions: live}, nil
+	})
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	cache.getSession(ctx, 7, "a", now)
+	cache.getSession(ctx, 7, "a", now)
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1 for a cached session", loads)
+	}
+
+	// A session that started after the state was cached is picked up.
+	sessions["b"] = true
+	if state, _ := cache.getSession(ctx, 7, "b", now); !state.Sessions["b"] {
+		t.Error("expected the new session to be live")
+	}
+
+	// A revoked session is gone once the user is forgotten.
+	delete(sessions, "a")
+	cache.forget(7)
+	if state, _ := cache.getSession(ctx, 7, "a", now); state.Sessions["a"] {
+		t.Error("expected the revoked session to be signed out")
+	}
+}
+
+func TestTokenStateCacheSignedOutSessionReloadsOncePerTTL(t *testing.T) {
+	loads := 0
+	cache := newTokenStateCache(time.Minute, func(ctx context.Context, userID int64) (tokenState, error) {
+		loads++
+		return tokenState{Sessions: map[string]bool{"live": true}}, nil
+	})
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	cache.getSession(ctx, 7, "live", now)
+	for i := 0; i < 5; i++ {
+		if state, _ := cache.getSession(ctx, 7, "gone", now.Add(time.Duration(i)*time.Second)); state.Sessions["gone"] {
+			t.Fatal("expected the signed-out session to be rejected")
+		}
+	}
+	if loads != 2 {
+		t.Errorf("loads = %d, want 2: the first load and one reload per ttl", loads)
+	}
+}
+
+func TestTokenStateCacheForgetIsPerUser(t *testing.T) {
+	release := make(chan struct{})
+	loading := make(chan struct{})
+	loads := map[int64]int{}
+	cache := newTokenStateCache(time.Minute, func(ctx context.Context, userID int64) (tokenState, error) {
+		loads[userID]++
+		if userID == 1 && loads[1] == 1 {
+			close(loading)
+			<-release
+		}
+		return tokenState{Version: int64(loads[userID])}, nil
+	})
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		cache.get(ctx, 1, now)
+		close(done)
+	}()
+	<-loading
+	// Forgetting user 2 while user 1 loads must not keep user 1 uncached.
+	cache.forget(2)
+	close(release)
+	<-done
+	cache.get(ctx, 1, now)
+	if loads[1] != 1 {
+		t.Errorf("user 1 loaded %d times, want 1", loads[1])
+	}
+}
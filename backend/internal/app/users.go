@@ -0,0 +1,218 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
+
+type userUpdateRequest struct {
+	Role     *string `json:"role"`
+	Disabled *bool   `json:"disabled"`
+	profileRequest
+}
+
+type userResponseItem struct {
+	ID         int64   `json:"id"`
+	Phone      string  `json:"phone"`
+	Role       string  `json:"role"`
+	Status     string  `json:"status"`
+	Disabled   bool    `json:"disabled"`
+	DisabledAt *string `json:"disabled_at,omitempty"`
+	// InviteCodeID is the invite code the account registered with.
+	InviteCodeID   *int64   `json:"invite_code_id"`
+	DisplayName    string   `json:"display_name"`
+	WechatNickname string   `json:"wechat_nickname"`
+	AvatarURL      string   `json:"avatar_url"`
+	Groups         []string `json:"groups"`
+	CreatedAt      string   `json:"created_at"`
+}
+
+// handleListUsers lists accounts for admins. q searches the phone number and
+// names; role, group and status (active or disabled) narrow the list.
+func (s *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, ok := parsePageQuery(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := userListFilter{
+		Query:  strings.TrimSpace(query.Get("q")),
+		Role:   strings.TrimSpace(query.Get("role")),
+		Group:  strings.TrimSpace(query.Get("group")),
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	}
+	if filter.Role != "" && !isValidRole(filter.Role) {
+		writeErr(w, http.StatusBadRequest, "invalid role")
+		return
+	}
+	switch status := strings.TrimSpace(query.Get("status")); status {
+	case "":
+	case "active", "disabled":
+		disabled := status == "disabled"
+		filter.Disabled = &disabled
+	default:
+		writeErr(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	users, err := listUsers(r.Context(), s.db, filter)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+	total, err := countUsers(r.Context(), s.db, filter)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+
+	items := make([]userResponseItem, 0, len(users))
+	for _, user := range users {
+		items = append(items, toUserResponseItem(user))
+	}
+	writePage(w, items, page, pageSize, total)
+}
+
+// parsePageQuery reads page and pageSize for the account listings that query
+// the store directly, applying the same defaults as the ledger listings.
+func parsePageQuery(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	page, err := parsePositiveQueryInt(r, "page")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return 0, 0, false
+	}
+	pageSize, err := parsePositiveQueryInt(r, "pageSize")
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return 0, 0, false
+	}
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = defaultUserPageSize
+	}
+	if pageSize > maxUserPageSize {
+		writeErr(w, http.StatusBadRequest, "invalid pageSize")
+		return 0, 0, false
+	}
+	return page, pageSize, true
+}
+
+func writePage(w http.ResponseWriter, items interface{}, page, pageSize int, total int64) {
+	totalPages := 0
+	if total > 0 {
+		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"items":       items,
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": totalPages,
+	})
+}
+
+func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid user id")
+	if !ok {
+		return
+	}
+
+	user, err := findUserProfile(r.Context(), s.db, int64(id))
+	if err != nil {
+		handleUserError(w, err, "failed to get user")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toUserResponseItem(user))
+}
+
+// handleUpdateUser changes the role of an account, disables or re-enables
+// it, and/or edits its profile. The request is validated first and applied
+// all at once, so it either succeeds as a whole or changes nothing.
+func (s *Server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePathID(w, r, "invalid user id")
+	if !ok {
+		return
+	}
+	var req userUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErr(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	profile, err := req.profileRequest.parse()
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Role == nil && req.Disabled == nil && profile.empty() {
+		writeErr(w, http.StatusBadRequest, "role, disabled or a profile field is required")
+		return
+	}
+	if req.Role != nil && !isValidRole(*req.Role) {
+		writeErr(w, http.StatusBadRequest, "invalid role")
+		return
+	}
+
+	if err := updateUser(r.Context(), s.db, int64(id), userUpdate{Role: req.Role, Disabled: req.Disabled, Profile: profile}); err != nil {
+		handleUserError(w, err, "failed to update user")
+		return
+	}
+	if req.Role != nil || req.Disabled != nil {
+		s.tokenStates.forget(int64(id))
+	}
+
+	user, err := findUserProfile(r.Context(), s.db, int64(id))
+	if err != nil {
+		handleUserError(w, err, "failed to get user")
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserResponseItem(user))
+}
+
+func handleUserError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeErr(w, http.StatusNotFound, "user not found")
+	case errors.Is(err, errLastAdmin):
+		writeErr(w, http.StatusConflict, err.Error())
+	default:
+		writeErr(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+func toUserResponseItem(user User) userResponseItem {
+	out := userResponseItem{
+		ID:             user.ID,
+		Phone:          user.Phone,
+		Role:           user.Role,
+		Status:         user.Status,
+		Disabled:       user.DisabledAt != nil,
+		InviteCodeID:   user.InviteCodeID,
+		DisplayName:    user.DisplayName,
+		WechatNickname: user.WechatNickname,
+		AvatarURL:      user.AvatarURL,
+		Groups:         user.Groups,
+		CreatedAt:      user.CreatedAt.Format(time.RFC3339),
+	}
+	if out.Groups == nil {
+		out.Groups = []string{}
+	}
+	if user.DisabledAt != nil {
+		disabledAt := user.DisabledAt.Format(time.RFC3339)
+		out.DisabledAt = &disabledAt
+	}
+	return out
+}
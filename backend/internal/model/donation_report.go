@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type DonationReportStatus string
+
+const (
+	DonationReportStatusPending   DonationReportStatus = "pending"
+	DonationReportStatusConfirmed DonationReportStatus = "confirmed"
+	DonationReportStatusRejected  DonationReportStatus = "rejected"
+	// DonationReportStatusUnbooked is a confirmed report whose donation is
+	// not booked yet. It is never stored: such rows are confirmed with no
+	// entry id.
+	DonationReportStatusUnbooked DonationReportStatus = "unbooked"
+)
+
+type DonationReport struct {
+	ID            uint64
+	ReporterID    uint64
+	Donor         string
+	GroupName     string
+	Amount        Money
+	DonatedAt     time.Time
+	ScreenshotURL string
+	Status        DonationReportStatus
+	ReviewedBy    *uint64
+	ReviewedAt    *time.Time
+	ReviewNote    string
+	EntryID       *uint64
+	CreatedAt     time.Time
+}
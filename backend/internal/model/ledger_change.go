@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type LedgerChangeAction string
+
+const (
+	LedgerChangeCreate LedgerChangeAction = "create"
+	LedgerChangeUpdate LedgerChangeAction = "update"
+	LedgerChangeDelete LedgerChangeAction = "delete"
+)
+
+type LedgerChangeStatus string
+
+const (
+	LedgerChangeStatusPending  LedgerChangeStatus = "pending"
+	LedgerChangeStatusApproved LedgerChangeStatus = "approved"
+	LedgerChangeStatusRejected LedgerChangeStatus = "rejected"
+)
+
+// LedgerChange is a ledger write above the approval threshold that waits for
+// a second admin. Payload keeps the original request so it can be replayed
+// once approved; EntryID is empty for a create until it is applied.
+type LedgerChange struct {
+	ID          uint64
+	Action      LedgerChangeAction
+	EntryID     *uint64
+	EntryType   LedgerEntryType
+	Amount      Money
+	Payload     json.RawMessage
+	Status      LedgerChangeStatus
+	RequestedBy uint64
+	ReviewedBy  *uint64
+	ReviewedAt  *time.Time
+	ReviewNote  string
+	CreatedAt   time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// LedgerComment is a message on a ledger entry. A comment without ParentID
+// opens a thread (a question); replies point at the thread they belong to.
+// Only threads carry the resolved state.
+type LedgerComment struct {
+	ID         uint64
+	EntryID    uint64
+	ParentID   *uint64
+	AuthorID   uint64
+	Body       string
+	Hidden     bool
+	HiddenBy   *uint64
+	ResolvedBy *uint64
+	ResolvedAt *time.Time
+	CreatedAt  time.Time
+}
@@ -2,7 +2,6 @@ package model
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -14,35 +13,85 @@ const (
 	LedgerEntryTypeExpense  LedgerEntryType = "expense"
 )
 
+type ExpenseCategory string
+
+const (
+	ExpenseCategoryMedical   ExpenseCategory = "medical"
+	ExpenseCategoryNeutering ExpenseCategory = "neutering"
+	ExpenseCategoryFood      ExpenseCategory = "food"
+	ExpenseCategorySupplies  ExpenseCategory = "supplies"
+	ExpenseCategoryShelter   ExpenseCategory = "shelter"
+	ExpenseCategoryOther     ExpenseCategory = "other"
+)
+
+var expenseCategories = []ExpenseCategory{
+	ExpenseCategoryMedical,
+	ExpenseCategoryNeutering,
+	ExpenseCategoryFood,
+	ExpenseCategorySupplies,
+	ExpenseCategoryShelter,
+	ExpenseCategoryOther,
+}
+
 type LedgerEntry struct {
 	ID          uint64
 	UserID      uint64
 	EntryType   LedgerEntryType
-	Amount      string
+	Amount      Money
 	OccurredAt  time.Time
 	Description string
+	Category    ExpenseCategory
 	MonthKey    string
 	CreatedAt   time.Time
+	Lines       []LedgerEntryLine
+	Tags        []string
+	// OpenQuestions counts unresolved comment threads; only listings fill it.
+	OpenQuestions int
+	// DeletedAt and DeletedBy are set on soft-deleted entries; only listings
+	// fill them.
+	DeletedAt *time.Time
+	DeletedBy *uint64
+	// RecorderName is the profile name of the user who recorded the entry;
+	// only listings fill it.
+	RecorderName string
+}
+
+// LedgerEntryLine splits an expense into individual purchases. Lines of an
+// entry always add up to the entry amount. An empty Category means the line
+// falls under the category of its entry.
+type LedgerEntryLine struct {
+	ID          uint64
+	EntryID     uint64
+	Description string
+	Amount      Money
+	Category    ExpenseCategory
 }
 
 func ValidateAmount(raw string) error {
-	amount := strings.TrimSpace(raw)
-	if amount == "" {
-		return fmt.Errorf("amount is required")
-	}
+	_, err := ParseAmount(raw)
+	return err
+}
 
-	parsed, err := strconv.ParseFloat(amount, 64)
-	if err != nil {
-		return fmt.Errorf("amount must be a valid decimal")
-	}
-	if parsed <= 0 {
-		return fmt.Errorf("amount must be greater than 0")
+// LineCategory resolves the category a line is counted under.
+func (e LedgerEntry) LineCategory(line LedgerEntryLine) ExpenseCategory {
+	if line.Category != "" {
+		return line.Category
 	}
+	return e.Category
+}
 
-	parts := strings.Split(amount, ".")
-	if len(parts) == 2 && len(parts[1]) > 2 {
-		return fmt.Errorf("amount must have at most 2 decimal places")
-	}
+func ExpenseCategories() []ExpenseCategory {
+	out := make([]ExpenseCategory, len(expenseCategories))
+	copy(out, expenseCategories)
+	return out
+}
 
-	return nil
+func ParseExpenseCategory(raw string) (ExpenseCategory, error) {
+	category := ExpenseCategory(strings.ToLower(strings.TrimSpace(raw)))
+	for _, known := range expenseCategories {
+		if category == known {
+			return category, nil
+		}
+	}
+	return "", fmt.Errorf("invalid category")
 }
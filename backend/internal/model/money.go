@@ -0,0 +1,148 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in fen (1/100 yuan).
+type Money int64
+
+// maxMoneyIntegerDigits matches DECIMAL(12,2) columns.
+const maxMoneyIntegerDigits = 10
+
+// ParseMoney parses a plain decimal such as "12", "12.5" or "-0.30". It does
+// not trim whitespace and rejects exponents, signs other than a leading '-',
+// a bare trailing or leading '.', and more than two decimal places.
+func ParseMoney(raw string) (Money, error) {
+	return parseMoney(raw, false)
+}
+
+// ParseAmount parses a user supplied ledger amount, which must be positive.
+func ParseAmount(raw string) (Money, error) {
+	if raw == "" {
+		return 0, fmt.Errorf("amount is required")
+	}
+	m, err := ParseMoney(raw)
+	if err != nil {
+		return 0, err
+	}
+	if m <= 0 {
+		return 0, fmt.Errorf("amount must be greater than 0")
+	}
+	return m, nil
+}
+
+func parseMoney(raw string, allowExtraZeros bool) (Money, error) {
+	s := raw
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasDot := strings.Cut(s, ".")
+	if intPart == "" || !isDigits(intPart) || (hasDot && (fracPart == "" || !isDigits(fracPart))) {
+		return 0, fmt.Errorf("amount must be a valid decimal")
+	}
+	if len(fracPart) > 2 {
+		if !allowExtraZeros || strings.TrimRight(fracPart[2:], "0") != "" {
+			return 0, fmt.Errorf("amount must have at most 2 decimal places")
+		}
+		fracPart = fracPart[:2]
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	if len(intPart) > maxMoneyIntegerDigits {
+		return 0, fmt.Errorf("amount is too large")
+	}
+
+	var yuan int64
+	if intPart != "" {
+		v, err := strconv.ParseInt(intPart, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("amount must be a valid decimal")
+		}
+		yuan = v
+	}
+	fracPart += strings.Repeat("0", 2-len(fracPart))
+	fen, _ := strconv.ParseInt(fracPart, 10, 64)
+
+	total := yuan*100 + fen
+	if negative {
+		total = -total
+	}
+	return Money(total), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount with exactly two decimals.
+func (m Money) String() string {
+	v := int64(m)
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("amount must be a string")
+	}
+	parsed, err := ParseMoney(raw)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads DECIMAL columns, which the MySQL driver returns as text. Results
+// of SUM or AVG may carry more than two decimals; those must be zeros.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		parsed, err := parseMoney(string(v), true)
+		if err != nil {
+			return fmt.Errorf("scan money %q: %w", v, err)
+		}
+		*m = parsed
+		return nil
+	case string:
+		parsed, err := parseMoney(v, true)
+		if err != nil {
+			return fmt.Errorf("scan money %q: %w", v, err)
+		}
+		*m = parsed
+		return nil
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	default:
+		return fmt.Errorf("scan money: unsupported type %T", src)
+	}
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	valid := map[string]Money{
+		"1":       100,
+		"12.5":    1250,
+		"0.01":    1,
+		"100.00":  10000,
+		"0012.30": 1230,
+	}
+	for raw, want := range valid {
+		got, err := ParseAmount(raw)
+		if err != nil {
+			t.Fatalf("ParseAmount(%q) error = %v", raw, err)
+		}
+		if got != want {
+			t.Fatalf("ParseAmount(%q) = %d, want %d", raw, got, want)
+		}
+	}
+
+	invalid := []string{"", " 0.1 ", "1e3", "100.", ".5", "+5", "-5", "0", "0.00", "1.234", "1,000", "NaN", "12345678901"}
+	for _, raw := range invalid {
+		if _, err := ParseAmount(raw); err == nil {
+			t.Fatalf("ParseAmount(%q) expected error", raw)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	cases := map[Money]string{
+		0:      "0.00",
+		5:      "0.05",
+		1230:   "12.30",
+		-1:     "-0.01",
+		-26612: "-266.12",
+	}
+	for m, want := range cases {
+		if got := m.String(); got != want {
+			t.Fatalf("Money(%d).String() = %q, want %q", int64(m), got, want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{Amount: 19100})
+	if err != nil {
+		t.Fatalf("Marshal error = %v", err)
+	}
+	if string(data) != `{"amount":"191.00"}` {
+		t.Fatalf("Marshal = %s", data)
+	}
+
+	var m Money
+	if err := json.Unmarshal([]byte(`"33.5"`), &m); err != nil || m != 3350 {
+		t.Fatalf("Unmarshal = %d, %v", m, err)
+	}
+	if err := json.Unmarshal([]byte(`33.5`), &m); err == nil {
+		t.Fatalf("Unmarshal of a JSON number expected error")
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	var m Money
+	if err := m.Scan([]byte("2661.2000")); err != nil || m != 266120 {
+		t.Fatalf("Scan = %d, %v", m, err)
+	}
+	if err := m.Scan([]byte("-15.5")); err != nil || m != -1550 {
+		t.Fatalf("Scan = %d, %v", m, err)
+	}
+	if err := m.Scan([]byte("1.005")); err == nil {
+		t.Fatalf("Scan of 1.005 expected error")
+	}
+}
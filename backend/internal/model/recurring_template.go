@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+type RecurrenceFrequency string
+
+const (
+	RecurrenceMonthly RecurrenceFrequency = "monthly"
+)
+
+// RecurringTemplate describes a ledger entry that repeats on a schedule. The
+// scheduler books one entry per period between StartDate and EndDate.
+type RecurringTemplate struct {
+	ID         uint64
+	EntryType  LedgerEntryType
+	Amount     Money
+	Donor      string
+	Purpose    string
+	HandledBy  string
+	Category   ExpenseCategory
+	Frequency  RecurrenceFrequency
+	DayOfMonth int
+	StartDate  time.Time
+	EndDate    *time.Time
+	Active     bool
+	LastPeriod string
+	CreatedBy  uint64
+	CreatedAt  time.Time
+}
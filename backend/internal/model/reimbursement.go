@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type ReimbursementStatus string
+
+const (
+	ReimbursementStatusPending  ReimbursementStatus = "pending"
+	ReimbursementStatusApproved ReimbursementStatus = "approved"
+	ReimbursementStatusRejected ReimbursementStatus = "rejected"
+	// ReimbursementStatusUnbooked is an approved request whose expense is not
+	// booked yet, because booking failed or still waits for a second admin.
+	// It is never stored: such rows are approved with no entry id.
+	ReimbursementStatusUnbooked ReimbursementStatus = "unbooked"
+)
+
+type Reimbursement struct {
+	ID          uint64
+	RequesterID uint64
+	Amount      Money
+	Purpose     string
+	Category    ExpenseCategory
+	OccurredAt  time.Time
+	ReceiptURL  string
+	Status      ReimbursementStatus
+	ReviewedBy  *uint64
+	ReviewedAt  *time.Time
+	ReviewNote  string
+	EntryID     *uint64
+	CreatedAt   time.Time
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"propets/backend/internal/model"
+)
+
+type CategoryBudget struct {
+	Category      model.ExpenseCategory
+	MonthlyAmount model.Money
+	UpdatedBy     uint64
+	UpdatedAt     time.Time
+}
+
+type UpsertCategoryBudgetInput struct {
+	Category      model.ExpenseCategory
+	MonthlyAmount model.Money
+	UpdatedBy     uint64
+}
+
+type CategoryBudgetUsage struct {
+	Category   model.ExpenseCategory
+	HasBudget  bool
+	Budget     model.Money
+	Actual     model.Money
+	Variance   model.Money
+	OverBudget bool
+}
+
+type BudgetRepository interface {
+	ListBudgets(ctx context.Context) ([]CategoryBudget, error)
+	UpsertBudget(ctx context.Context, input UpsertCategoryBudgetInput) error
+	DeleteBudget(ctx context.Context, category model.ExpenseCategory) error
+	ListCategoryBudgetUsage(ctx context.Context, monthKey string) ([]CategoryBudgetUsage, error)
+}
+
+type SQLBudgetRepository struct {
+	db *sql.DB
+}
+
+func NewSQLBudgetRepository(db *sql.DB) *SQLBudgetRepository {
+	return &SQLBudgetRepository{db: db}
+}
+
+var ErrBudgetNotFound = errors.New("budget not found")
+
+const listCategoryBudgetsSQL = `
+SELECT category, monthly_amount, updated_by, updated_at
+FROM ledger_category_budgets
+ORDER BY category ASC
+`
+
+const upsertCategoryBudgetSQL = `
+INSERT INTO ledger_category_budgets (category, monthly_amount, updated_by)
+VALUES (?, ?, ?)
+ON DUPLICATE KEY UPDATE monthly_amount = VALUES(monthly_amount), updated_by = VALUES(updated_by)
+`
+
+// listCategoryBudgetUsageSQL counts split expenses per line item so each
+// line lands in its own category; expenses without lines count as a whole.
+const listCategoryBudgetUsageSQL = `
+WITH allocations AS (
+	SELECT COALESCE(NULLIF(lines.category, ''), entries.category) AS category, lines.amount
+	FROM ledger_entries AS entries
+	JOIN ledger_entry_lines AS lines ON lines.entry_id = entries.id
+	WHERE entries.entry_type = 'expense' AND entries.month_key = ? AND entries.deleted_at IS NULL
+	UNION ALL
+	SELECT entries.category, entries.amount
+	FROM ledger_entries AS entries
+	WHERE entries.entry_type = 'expense' AND entries.month_key = ? AND entries.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM ledger_entry_lines AS lines WHERE lines.entry_id = entries.id)
+),
+totals AS (
+	SELECT category, SUM(amount) AS actual
+	FROM allocations
+	GROUP BY category
+)
+SELECT
+	categories.category,
+	budgets.category IS NOT NULL AS has_budget,
+	COALESCE(budgets.monthly_amount, 0) AS budget,
+	COALESCE(totals.actual, 0) AS actual,
+	COALESCE(budgets.monthly_amount, 0) - COALESCE(totals.actual, 0) AS variance,
+	budgets.category IS NOT NULL AND COALESCE(totals.actual, 0) > budgets.monthly_amount AS over_budget
+FROM (
+	SELECT category FROM ledger_category_budgets
+	UNION
+	SELECT category FROM totals
+) AS categories
+LEFT JOIN ledger_category_budgets AS budgets ON budgets.category = categories.category
+LEFT JOIN totals ON totals.category = categories.category
+ORDER BY categories.category ASC
+`
+
+func (r *SQLBudgetRepository) ListBudgets(ctx context.Context) ([]CategoryBudget, error) {
+	rows, err := r.db.QueryContext(ctx, listCategoryBudgetsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]CategoryBudget, 0)
+	for rows.Next() {
+		var item CategoryBudget
+		if err := rows.Scan(&item.Category, &item.MonthlyAmount, &item.UpdatedBy, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *SQLBudgetRepository) UpsertBudget(ctx context.Context, input UpsertCategoryBudgetInput) error {
+	_, err := r.db.ExecContext(ctx, upsertCategoryBudgetSQL, input.Category, input.MonthlyAmount, input.UpdatedBy)
+	return err
+}
+
+func (r *SQLBudgetRepository) DeleteBudget(ctx context.Context, category model.ExpenseCategory) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM ledger_category_budgets WHERE category = ?`, category)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+func (r *SQLBudgetRepository) ListCategoryBudgetUsage(ctx context.Context, monthKey string) ([]CategoryBudgetUsage, error) {
+	monthKey = strings.TrimSpace(monthKey)
+	if monthKey == "" || !monthFilterPattern.MatchString(monthKey) {
+		return nil, ErrInvalidMonthFilter
+	}
+
+	rows, err := r.db.QueryContext(ctx, listCategoryBudgetUsageSQL, monthKey, monthKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]CategoryBudgetUsage, 0)
+	for rows.Next() {
+		var item CategoryBudgetUsage
+		if err := rows.Scan(&item.Category, &item.HasBudget, &item.Budget, &item.Actual, &item.Variance, &item.OverBudget); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"propets/backend/internal/model"
+)
+
+type CreateCommentInput struct {
+	EntryID  uint64
+	ParentID *uint64
+	AuthorID uint64
+	Body     string
+}
+
+type CommentRepository interface {
+	CreateComment(ctx context.Context, input CreateCommentInput) (uint64, error)
+	GetCommentByID(ctx context.Context, id uint64) (model.LedgerComment, error)
+	ListComments(ctx context.Context, entryID uint64, includeHidden bool) ([]model.LedgerComment, error)
+	SetThreadResolved(ctx context.Context, id uint64, resolvedBy *uint64) error
+	SetCommentHidden(ctx context.Context, id uint64, hiddenBy *uint64) error
+}
+
+type SQLCommentRepository struct {
+	db *sql.DB
+}
+
+func NewSQLCommentRepository(db *sql.DB) *SQLCommentRepository {
+	return &SQLCommentRepository{db: db}
+}
+
+var ErrCommentNotFound = errors.New("comment not found")
+
+const insertCommentSQL = `
+INSERT INTO ledger_entry_comments (entry_id, parent_id, author_id, body)
+VALUES (?, ?, ?, ?)
+`
+
+const listCommentsBaseSQL = `
+SELECT id, entry_id, parent_id, author_id, body, hidden, hidden_by, resolved_by, resolved_at, created_at
+FROM ledger_entry_comments
+`
+
+func (r *SQLCommentRepository) CreateComment(ctx context.Context, input CreateCommentInput) (uint64, error) {
+	var parentID interface{}
+	if input.ParentID != nil {
+		parentID = *input.ParentID
+	}
+	res, err := r.db.ExecContext(ctx, insertCommentSQL, input.EntryID, parentID, input.AuthorID, input.Body)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *SQLCommentRepository) GetCommentByID(ctx context.Context, id uint64) (model.LedgerComment, error) {
+	item, err := scanComment(r.db.QueryRowContext(ctx, listCommentsBaseSQL+" WHERE id = ? LIMIT 1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.LedgerComment{}, ErrCommentNotFound
+	}
+	return item, err
+}
+
+func (r *SQLCommentRepository) ListComments(ctx context.Context, entryID uint64, includeHidden bool) ([]model.LedgerComment, error) {
+	query := listCommentsBaseSQL + " WHERE entry_id = ?"
+	if !includeHidden {
+		query += " AND hidden = FALSE"
+	}
+	query += " ORDER BY created_at ASC, id ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.LedgerComment, 0)
+	for rows.Next() {
+		item, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// SetThreadResolved marks a thread resolved by the given admin, or reopens it
+// when resolvedBy is nil.
+func (r *SQLCommentRepository) SetThreadResolved(ctx context.Context, id uint64, resolvedBy *uint64) error {
+	const resolveSQL = `UPDATE ledger_entry_comments SET resolved_by = ?, resolved_at = NOW() WHERE id = ? AND parent_id IS NULL`
+	const reopenSQL = `UPDATE ledger_entry_comments SET resolved_by = NULL, resolved_at = NULL WHERE id = ? AND parent_id IS NULL`
+
+	var err error
+	if resolvedBy != nil {
+		_, err = r.db.ExecContext(ctx, resolveSQL, *resolvedBy, id)
+	} else {
+		_, err = r.db.ExecContext(ctx, reopenSQL, id)
+	}
+	return err
+}
+
+// SetCommentHidden hides the comment on behalf of the given admin, or shows
+// it again when hiddenBy is nil.
+func (r *SQLCommentRepository) SetCommentHidden(ctx context.Context, id uint64, hiddenBy *uint64) error {
+	var by interface{}
+	if hiddenBy != nil {
+		by = *hiddenBy
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE ledger_entry_comments SET hidden = ?, hidden_by = ? WHERE id = ?`, hiddenBy != nil, by, id)
+	return err
+}
+
+func scanComment(row rowScanner) (model.LedgerComment, error) {
+	var item model.LedgerComment
+	var parentID, hiddenBy, resolvedBy sql.NullInt64
+	var resolvedAt sql.NullTime
+	if err := row.Scan(
+		&item.ID,
+		&item.EntryID,
+		&parentID,
+		&item.AuthorID,
+		&item.Body,
+		&item.Hidden,
+		&hiddenBy,
+		&resolvedBy,
+		&resolvedAt,
+		&item.CreatedAt,
+	); err != nil {
+		return model.LedgerComment{}, err
+	}
+	if parentID.Valid {
+		v := uint64(parentID.Int64)
+		item.ParentID = &v
+	}
+	if hiddenBy.Valid {
+		v := uint64(hiddenBy.Int64)
+		item.HiddenBy = &v
+	}
+	if resolvedBy.Valid {
+		v := uint64(resolvedBy.Int64)
+		item.ResolvedBy = &v
+	}
+	if resolvedAt.Valid {
+		v := resolvedAt.Time
+		item.ResolvedAt = &v
+	}
+	return item, nil
+}
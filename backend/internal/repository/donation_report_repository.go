@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"propets/backend/internal/model"
+)
+
+type CreateDonationReportInput struct {
+	ReporterID    uint64
+	Donor         string
+	GroupName     string
+	Amount        model.Money
+	DonatedAt     time.Time
+	ScreenshotURL string
+}
+
+type ListDonationReportsFilter struct {
+	ReporterID uint64
+	Status     model.DonationReportStatus
+	Limit      int
+	Offset     int
+}
+
+type DonationReportRepository interface {
+	CreateDonationReport(ctx context.Context, input CreateDonationReportInput) (uint64, error)
+	GetDonationReportByID(ctx context.Context, id uint64) (model.DonationReport, error)
+	ListDonationReports(ctx context.Context, filter ListDonationReportsFilter) ([]model.DonationReport, error)
+	CountDonationReports(ctx context.Context, filter ListDonationReportsFilter) (int64, error)
+	ClaimDonationReport(ctx context.Context, id, reviewerID uint64) (model.DonationReport, error)
+	SetDonationReportEntry(ctx context.Context, id, entryID uint64) error
+	MarkDonationReportRejected(ctx context.Context, id, reviewerID uint64, note string) error
+}
+
+type SQLDonationReportRepository struct {
+	db *sql.DB
+}
+
+func NewSQLDonationReportRepository(db *sql.DB) *SQLDonationReportRepository {
+	return &SQLDonationReportRepository{db: db}
+}
+
+var (
+	ErrDonationReportNotFound   = errors.New("donation report not found")
+	ErrDonationReportNotPending = errors.New("donation report is not pending")
+)
+
+const insertDonationReportSQL = `
+INSERT INTO donation_reports (reporter_id, donor, group_name, amount, donated_at, screenshot_url)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+const listDonationReportsBaseSQL = `
+SELECT id, reporter_id, donor, group_name, amount, donated_at, screenshot_url, status, reviewed_by, reviewed_at, review_note, entry_id, created_at
+FROM donation_reports
+`
+
+func (r *SQLDonationReportRepository) CreateDonationReport(ctx context.Context, input CreateDonationReportInput) (uint64, error) {
+	res, err := r.db.ExecContext(ctx, insertDonationReportSQL, input.ReporterID, input.Donor, input.GroupName, input.Amount, input.DonatedAt, input.ScreenshotURL)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *SQLDonationReportRepository) GetDonationReportByID(ctx context.Context, id uint64) (model.DonationReport, error) {
+	item, err := scanDonationReport(r.db.QueryRowContext(ctx, listDonationReportsBaseSQL+" WHERE id = ? LIMIT 1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.DonationReport{}, ErrDonationReportNotFound
+	}
+	return item, err
+}
+
+func (r *SQLDonationReportRepository) ListDonationReports(ctx context.Context, filter ListDonationReportsFilter) ([]model.DonationReport, error) {
+	whereSQL, args := buildDonationReportFilterClause(filter)
+	query := listDonationReportsBaseSQL + whereSQL + " ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.DonationReport, 0)
+	for rows.Next() {
+		item, err := scanDonationReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *SQLDonationReportRepository) CountDonationReports(ctx context.Context, filter ListDonationReportsFilter) (int64, error) {
+	whereSQL, args := buildDonationReportFilterClause(filter)
+	var total int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(1) FROM donation_reports"+whereSQL, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// ClaimDonationReport moves a pending report to confirmed by reviewerID
+// before its donation is booked, the same way reimbursements are claimed. A
+// report that was claimed but has no entry yet is returned as is, so an
+// interrupted confirmation can be finished by retrying it.
+func (r *SQLDonationReportRepository) ClaimDonationReport(ctx context.Context, id, reviewerID uint64) (model.DonationReport, error) {
+	const claimSQL = `
+UPDATE donation_reports
+SET status = 'confirmed', reviewed_by = ?, reviewed_at = NOW()
+WHERE id = ? AND status = 'pending'
+`
+	res, err := r.db.ExecContext(ctx, claimSQL, reviewerID, id)
+	if err != nil {
+		return model.DonationReport{}, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return model.DonationReport{}, err
+	}
+
+	current, err := r.GetDonationReportByID(ctx, id)
+	if err != nil {
+		return model.DonationReport{}, err
+	}
+	if affected == 0 && current.Status != model.DonationReportStatusUnbooked {
+		return model.DonationReport{}, ErrDonationReportNotPending
+	}
+	return current, nil
+}
+
+// SetDonationReportEntry links a claimed report to the donation it produced.
+// Setting the same entry again is a no-op, like reimbursement approval.
+func (r *SQLDonationReportRepository) SetDonationReportEntry(ctx context.Context, id, entryID uint64) error {
+	const setEntrySQL = `
+UPDATE donation_reports
+SET entry_id = ?
+WHERE id = ? AND status = 'confirmed' AND (entry_id IS NULL OR entry_id = ?)
+`
+	res, err := r.db.ExecContext(ctx, setEntrySQL, entryID, id, entryID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	current, err := r.GetDonationReportByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.EntryID != nil && *current.EntryID == entryID {
+		return nil
+	}
+	return ErrDonationReportNotPending
+}
+
+func (r *SQLDonationReportRepository) MarkDonationReportRejected(ctx context.Context, id, reviewerID uint64, note string) error {
+	const rejectSQL = `
+UPDATE donation_reports
+SET status = 'rejected', reviewed_by = ?, reviewed_at = NOW(), review_note = ?
+WHERE id = ? AND status = 'pending'
+`
+	res, err := r.db.ExecContext(ctx, rejectSQL, reviewerID, note, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	if _, err := r.GetDonationReportByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrDonationReportNotPending
+}
+
+func scanDonationReport(row rowScanner) (model.DonationReport, error) {
+	var item model.DonationReport
+	var reviewedBy, entryID sql.NullInt64
+	var reviewedAt sql.NullTime
+	if err := row.Scan(
+		&item.ID,
+		&item.ReporterID,
+		&item.Donor,
+		&item.GroupName,
+		&item.Amount,
+		&item.DonatedAt,
+		&item.ScreenshotURL,
+		&item.Status,
+		&reviewedBy,
+		&reviewedAt,
+		&item.ReviewNote,
+		&entryID,
+		&item.CreatedAt,
+	); err != nil {
+		return model.DonationReport{}, err
+	}
+	if reviewedBy.Valid {
+		v := uint64(reviewedBy.Int64)
+		item.ReviewedBy = &v
+	}
+	if reviewedAt.Valid {
+		v := reviewedAt.Time
+		item.ReviewedAt = &v
+	}
+	if entryID.Valid {
+		v := uint64(entryID.Int64)
+		item.EntryID = &v
+	} else if item.Status == model.DonationReportStatusConfirmed {
+		item.Status = model.DonationReportStatusUnbooked
+	}
+	return item, nil
+}
+
+func buildDonationReportFilterClause(filter ListDonationReportsFilter) (string, []interface{}) {
+	clauses := make([]string, 0, 2)
+	args := make([]interface{}, 0, 2)
+
+	if filter.ReporterID != 0 {
+		clauses = append(clauses, "reporter_id = ?")
+		args = append(args, filter.ReporterID)
+	}
+	switch filter.Status {
+	case "":
+	case model.DonationReportStatusConfirmed:
+		clauses = append(clauses, "status = 'confirmed' AND entry_id IS NOT NULL")
+	case model.DonationReportStatusUnbooked:
+		clauses = append(clauses, "status = 'confirmed' AND entry_id IS NULL")
+	default:
+		clauses = append(clauses, "status = ?")
+		args = append(args, string(filter.Status))
+	}
+	if len(clauses) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
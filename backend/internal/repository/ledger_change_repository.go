@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"propets/backend/internal/model"
+)
+
+type CreateLedgerChangeInput struct {
+	Action      model.LedgerChangeAction
+	EntryID     *uint64
+	EntryType   model.LedgerEntryType
+	Amount      model.Money
+	Payload     json.RawMessage
+	RequestedBy uint64
+	// RequestID deduplicates retried create requests; empty for edits and
+	// deletions, which are keyed by the entry instead.
+	RequestID string
+}
+
+type ListLedgerChangesFilter struct {
+	Status  model.LedgerChangeStatus
+	EntryID uint64
+	Limit   int
+	Offset  int
+}
+
+type LedgerChangeRepository interface {
+	CreateChange(ctx context.Context, input CreateLedgerChangeInput) (uint64, bool, error)
+	GetChangeByID(ctx context.Context, id uint64) (model.LedgerChange, error)
+	ListChanges(ctx context.Context, filter ListLedgerChangesFilter) ([]model.LedgerChange, error)
+	CountChanges(ctx context.Context, filter ListLedgerChangesFilter) (int64, error)
+	ClaimChange(ctx context.Context, id, reviewerID uint64) error
+	ReleaseChange(ctx context.Context, id, reviewerID uint64) error
+	SetChangeEntry(ctx context.Context, id, entryID uint64) error
+	MarkChangeRejected(ctx context.Context, id, reviewerID uint64, note string) error
+}
+
+type SQLLedgerChangeRepository struct {
+	db *sql.DB
+}
+
+func NewSQLLedgerChangeRepository(db *sql.DB) *SQLLedgerChangeRepository {
+	return &SQLLedgerChangeRepository{db: db}
+}
+
+var (
+	ErrLedgerChangeNotFound   = errors.New("ledger change not found")
+	ErrLedgerChangeNotPending = errors.New("ledger change is not pending")
+)
+
+const insertLedgerChangeSQL = `
+INSERT INTO ledger_change_requests (action, entry_id, entry_type, amount, payload, requested_by, request_id)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+const listLedgerChangesBaseSQL = `
+SELECT id, action, entry_id, entry_type, amount, payload, status, requested_by, reviewed_by, reviewed_at, review_note, created_at
+FROM ledger_change_requests
+`
+
+func (r *SQLLedgerChangeRepository) CreateChange(ctx context.Context, input CreateLedgerChangeInput) (uint64, bool, error) {
+	var entryID, requestID interface{}
+	if input.EntryID != nil {
+		entryID = *input.EntryID
+	}
+	if input.RequestID != "" {
+		requestID = input.RequestID
+	}
+
+	res, err := r.db.ExecContext(
+		ctx,
+		insertLedgerChangeSQL,
+		input.Action,
+		entryID,
+		input.EntryType,
+		input.Amount,
+		[]byte(input.Payload),
+		input.RequestedBy,
+		requestID,
+	)
+	if err != nil {
+		if input.RequestID == "" || !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
+			return 0, false, err
+		}
+		return r.findChangeByRequestID(ctx, input)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, false, err
+	}
+	return uint64(id), false, nil
+}
+
+// findChangeByRequestID resolves a retried create to the change it already
+// queued, following the same ownership rules as the ledger idempotency keys.
+func (r *SQLLedgerChangeRepository) findChangeByRequestID(ctx context.Context, input CreateLedgerChangeInput) (uint64, bool, error) {
+	const findSQL = `SELECT id, action, requested_by FROM ledger_change_requests WHERE request_id = ? LIMIT 1`
+	var id, requestedBy uint64
+	var action string
+	if err := r.db.QueryRowContext(ctx, findSQL, input.RequestID).Scan(&id, &action, &requestedBy); err != nil {
+		return 0, false, err
+	}
+	if model.LedgerChangeAction(action) != input.Action || requestedBy != input.RequestedBy {
+		return 0, false, ErrIdempotencyConflict
+	}
+	return id, true, nil
+}
+
+func (r *SQLLedgerChangeRepository) GetChangeByID(ctx context.Context, id uint64) (model.LedgerChange, error) {
+	item, err := scanLedgerChange(r.db.QueryRowContext(ctx, listLedgerChangesBaseSQL+" WHERE id = ? LIMIT 1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.LedgerChange{}, ErrLedgerChangeNotFound
+	}
+	return item, err
+}
+
+func (r *SQLLedgerChangeRepository) ListChanges(ctx context.Context, filter ListLedgerChangesFilter) ([]model.LedgerChange, error) {
+	whereSQL, args := buildLedgerChangeFilterClause(filter)
+	order := " ORDER BY created_at DESC, id DESC"
+	if filter.EntryID != 0 {
+		// An entry's history reads oldest first.
+		order = " ORDER BY created_at ASC, id ASC"
+	}
+	query := listLedgerChangesBaseSQL + whereSQL + order + " LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.LedgerChange, 0)
+	for rows.Next() {
+		item, err := scanLedgerChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *SQLLedgerChangeRepository) CountChanges(ctx context.Context, filter ListLedgerChangesFilter) (int64, error) {
+	whereSQL, args := buildLedgerChangeFilterClause(filter)
+	var total int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(1) FROM ledger_change_requests"+whereSQL, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// ClaimChange marks a pending change approved by reviewerID before it is
+// applied, so a concurrent approval or rejection of the same change fails
+// instead of racing it. The requester can never approve their own change.
+func (r *SQLLedgerChangeRepository) ClaimChange(ctx context.Context, id, reviewerID uint64) error {
+	const claimSQL = `
+UPDATE ledger_change_requests
+SET status = 'approved', reviewed_by = ?, reviewed_at = NOW()
+WHERE id = ? AND status = 'pending' AND requested_by <> ?
+`
+	res, err := r.db.ExecContext(ctx, claimSQL, reviewerID, id, reviewerID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	if _, err := r.GetChangeByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrLedgerChangeNotPending
+}
+
+// ReleaseChange returns a change claimed by reviewerID to pending after it
+// could not be applied, so it can be approved again or rejected.
+func (r *SQLLedgerChangeRepository) ReleaseChange(ctx context.Context, id, reviewerID uint64) error {
+	const releaseSQL = `
+UPDATE ledger_change_requests
+SET status = 'pending', reviewed_by = NULL, reviewed_at = NULL
+WHERE id = ? AND status = 'approved' AND reviewed_by = ?
+`
+	_, err := r.db.ExecContext(ctx, releaseSQL, id, reviewerID)
+	return err
+}
+
+// SetChangeEntry records the entry an approved create was booked as.
+func (r *SQLLedgerChangeRepository) SetChangeEntry(ctx context.Context, id, entryID uint64) error {
+	const setEntrySQL = `UPDATE ledger_change_requests SET entry_id = ? WHERE id = ? AND status = 'approved'`
+	_, err := r.db.ExecContext(ctx, setEntrySQL, entryID, id)
+	return err
+}
+
+func (r *SQLLedgerChangeRepository) MarkChangeRejected(ctx context.Context, id, reviewerID uint64, note string) error {
+	const rejectSQL = `
+UPDATE ledger_change_requests
+SET status = 'rejected', reviewed_by = ?, reviewed_at = NOW(), review_note = ?
+WHERE id = ? AND status = 'pending'
+`
+	res, err := r.db.ExecContext(ctx, rejectSQL, reviewerID, note, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	if _, err := r.GetChangeByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrLedgerChangeNotPending
+}
+
+func scanLedgerChange(row rowScanner) (model.LedgerChange, error) {
+	var item model.LedgerChange
+	var entryID, reviewedBy sql.NullInt64
+	var reviewedAt sql.NullTime
+	var payload []byte
+	if err := row.Scan(
+		&item.ID,
+		&item.Action,
+		&entryID,
+		&item.EntryType,
+		&item.Amount,
+		&payload,
+		&item.Status,
+		&item.RequestedBy,
+		&reviewedBy,
+		&reviewedAt,
+		&item.ReviewNote,
+		&item.CreatedAt,
+	); err != nil {
+		return model.LedgerChange{}, err
+	}
+	item.Payload = json.RawMessage(payload)
+	if entryID.Valid {
+		v := uint64(entryID.Int64)
+		item.EntryID = &v
+	}
+	if reviewedBy.Valid {
+		v := uint64(reviewedBy.Int64)
+		item.ReviewedBy = &v
+	}
+	if reviewedAt.Valid {
+		v := reviewedAt.Time
+		item.ReviewedAt = &v
+	}
+	return item, nil
+}
+
+func buildLedgerChangeFilterClause(filter ListLedgerChangesFilter) (string, []interface{}) {
+	clauses := make([]string, 0, 2)
+	args := make([]interface{}, 0, 2)
+
+	if filter.Status != "" {
+		clauses = append(clauses, "status = ?")
+		args = append(args, string(filter.Status))
+	}
+	if filter.EntryID != 0 {
+		clauses = append(clauses, "entry_id = ?")
+		args = append(args, filter.EntryID)
+	}
+	if len(clauses) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
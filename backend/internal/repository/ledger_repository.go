@@ -14,36 +14,60 @@ import (
 type CreateLedgerEntryInput struct {
 	UserID      uint64
 	EntryType   model.LedgerEntryType
-	Amount      string
+	Amount      model.Money
 	OccurredAt  time.Time
 	Description string
+	Category    model.ExpenseCategory
+	Lines       []LedgerEntryLineInput
+	Tags        []string
 }
 
 type UpdateLedgerEntryInput struct {
 	EntryID     uint64
-	Amount      string
+	Amount      model.Money
 	OccurredAt  time.Time
 	Description string
+	Category    model.ExpenseCategory
+	Lines       []LedgerEntryLineInput
+	// Tags replaces every tag of the entry.
+	Tags []string
+}
+
+type LedgerEntryLineInput struct {
+	Description string
+	Amount      model.Money
+	Category    model.ExpenseCategory
 }
 
 type ListLedgerEntriesFilter struct {
 	MonthKey string
 	Type     model.LedgerEntryType
-	Limit    int
-	Offset   int
+	Tag      string
+	// Deleted lists soft-deleted entries instead of live ones.
+	Deleted bool
+	Limit   int
+	Offset  int
 }
 
 type MonthlySummary struct {
-	DonationTotal string
-	ExpenseTotal  string
-	Balance       string
+	DonationTotal model.Money
+	ExpenseTotal  model.Money
+	Balance       model.Money
 }
 
 type MonthlyStatistic struct {
 	MonthKey          string
-	DonationTotal     string
-	ExpenseTotal      string
-	CumulativeBalance string
+	DonationTotal     model.Money
+	ExpenseTotal      model.Money
+	CumulativeBalance model.Money
+}
+
+// TagUsage sums the live entries carrying a tag.
+type TagUsage struct {
+	Name          string
+	EntryCount    int64
+	DonationTotal model.Money
+	ExpenseTotal  model.Money
 }
 
 type LedgerRepository interface {
@@ -53,9 +77,12 @@ type LedgerRepository interface {
 	ListEntries(ctx context.Context, filter ListLedgerEntriesFilter) ([]model.LedgerEntry, error)
 	CountEntries(ctx context.Context, filter ListLedgerEntriesFilter) (int64, error)
 	GetMonthlySummary(ctx context.Context, monthKey string) (MonthlySummary, error)
-	ListMonthlyStatistics(ctx context.Context) ([]MonthlyStatistic, error)
+	// ListMonthlyStatistics fills every month from the first entry up to
+	// currentMonth, which the caller computes in the organization timezone.
+	ListMonthlyStatistics(ctx context.Context, currentMonth string) ([]MonthlyStatistic, error)
 	UpdateEntry(ctx context.Context, input UpdateLedgerEntryInput) error
 	SoftDeleteEntry(ctx context.Context, entryID uint64, deletedBy uint64) error
+	ListTagUsage(ctx context.Context) ([]TagUsage, error)
 }
 
 type SQLLedgerRepository struct {
@@ -67,12 +94,19 @@ func NewSQLLedgerRepository(db *sql.DB) *SQLLedgerRepository {
 }
 
 const insertLedgerEntrySQL = `
-INSERT INTO ledger_entries (user_id, entry_type, amount, occurred_at, description)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO ledger_entries (user_id, entry_type, amount, occurred_at, description, category)
+VALUES (?, ?, ?, ?, ?, ?)
 `
 
+// listLedgerEntriesBaseSQL names the recorder by display name, falling back
+// to the WeChat nickname; accounts without either give an empty name.
 const listLedgerEntriesBaseSQL = `
-SELECT id, user_id, entry_type, amount, occurred_at, description, month_key, created_at
+SELECT id, user_id, entry_type, amount, occurred_at, description, category, month_key, created_at, deleted_at, deleted_by,
+  COALESCE((
+    SELECT COALESCE(NULLIF(u.display_name, ''), u.wechat_nickname)
+    FROM users u
+    WHERE u.id = ledger_entries.user_id
+  ), '') AS recorder_name
 FROM ledger_entries
 `
 
@@ -95,12 +129,22 @@ WHERE request_id = ?
 `
 
 const getLedgerEntryByIDSQL = `
-SELECT id, user_id, entry_type, amount, occurred_at, description, month_key, created_at
+SELECT id, user_id, entry_type, amount, occurred_at, description, category, month_key, created_at
 FROM ledger_entries
 WHERE id = ?
 LIMIT 1
 `
 
+const insertLedgerEntryLineSQL = `
+INSERT INTO ledger_entry_lines (entry_id, line_no, description, amount, category)
+VALUES (?, ?, ?, ?, ?)
+`
+
+const listLedgerEntryLinesBaseSQL = `
+SELECT id, entry_id, description, amount, category
+FROM ledger_entry_lines
+`
+
 var (
 	ErrLedgerEntryNotFound      = errors.New("ledger entry not found")
 	ErrEntryAlreadyDeleted      = errors.New("entry already deleted")
@@ -113,7 +157,17 @@ var (
 var monthFilterPattern = regexp.MustCompile(`^\d{4}-(0[1-9]|1[0-2])$`)
 
 func (r *SQLLedgerRepository) CreateEntry(ctx context.Context, input CreateLedgerEntryInput) (uint64, error) {
-	res, err := r.db.ExecContext(ctx, insertLedgerEntrySQL, input.UserID, input.EntryType, input.Amount, input.OccurredAt, input.Description)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, insertLedgerEntrySQL, input.UserID, input.EntryType, input.Amount, input.OccurredAt, input.Description, input.Category)
 	if err != nil {
 		return 0, err
 	}
@@ -121,50 +175,185 @@ func (r *SQLLedgerRepository) CreateEntry(ctx context.Context, input CreateLedge
 	if err != nil {
 		return 0, err
 	}
+	if err = insertEntryLines(ctx, tx, uint64(id), input.Lines); err != nil {
+		return 0, err
+	}
+	if err = insertEntryTags(ctx, tx, uint64(id), input.Tags); err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
 	return uint64(id), nil
 }
 
 func (r *SQLLedgerRepository) UpdateEntry(ctx context.Context, input UpdateLedgerEntryInput) error {
+	const lockEntrySQL = `SELECT deleted_at FROM ledger_entries WHERE id = ? LIMIT 1 FOR UPDATE`
 	const updateEntrySQL = `
 	UPDATE ledger_entries
-	SET amount = ?, occurred_at = ?, description = ?
+	SET amount = ?, occurred_at = ?, description = ?, category = ?
 	WHERE id = ? AND deleted_at IS NULL
 	`
 
-	res, err := r.db.ExecContext(
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var deletedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, lockEntrySQL, input.EntryID).Scan(&deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrLedgerEntryNotFound
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if deletedAt.Valid {
+		err = ErrEntryAlreadyDeleted
+		return err
+	}
+
+	if _, err = tx.ExecContext(
 		ctx,
 		updateEntrySQL,
 		input.Amount,
 		input.OccurredAt,
 		input.Description,
+		input.Category,
 		input.EntryID,
-	)
-	if err != nil {
+	); err != nil {
 		return err
 	}
 
-	affected, err := res.RowsAffected()
-	if err != nil {
+	if _, err = tx.ExecContext(ctx, `DELETE FROM ledger_entry_lines WHERE entry_id = ?`, input.EntryID); err != nil {
+		return err
+	}
+	if err = insertEntryLines(ctx, tx, input.EntryID, input.Lines); err != nil {
 		return err
 	}
-	if affected > 0 {
-		return nil
+	if _, err = tx.ExecContext(ctx, `DELETE FROM ledger_entry_tags WHERE entry_id = ?`, input.EntryID); err != nil {
+		return err
+	}
+	if err = insertEntryTags(ctx, tx, input.EntryID, input.Tags); err != nil {
+		return err
 	}
 
-	const checkDeletedSQL = `SELECT deleted_at FROM ledger_entries WHERE id = ? LIMIT 1`
-	var deletedAt sql.NullTime
-	err = r.db.QueryRowContext(ctx, checkDeletedSQL, input.EntryID).Scan(&deletedAt)
-	if errors.Is(err, sql.ErrNoRows) {
-		return ErrLedgerEntryNotFound
+	return tx.Commit()
+}
+
+func insertEntryLines(ctx context.Context, tx *sql.Tx, entryID uint64, lines []LedgerEntryLineInput) error {
+	for i, line := range lines {
+		if _, err := tx.ExecContext(ctx, insertLedgerEntryLineSQL, entryID, i+1, line.Description, line.Amount, line.Category); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// insertEntryTags links the entry to its tags, creating tags on first use.
+// Names that differ only in ways the column collation ignores, such as
+// accents, resolve to the same tag, so linking it again is a no-op.
+func insertEntryTags(ctx context.Context, tx *sql.Tx, entryID uint64, tags []string) error {
+	const upsertTagSQL = `INSERT INTO ledger_tags (name) VALUES (?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`
+	const linkTagSQL = `INSERT INTO ledger_entry_tags (entry_id, tag_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE tag_id = tag_id`
+
+	for _, tag := range tags {
+		res, err := tx.ExecContext(ctx, upsertTagSQL, tag)
+		if err != nil {
+			return err
+		}
+		tagID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, linkTagSQL, entryID, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadEntryTags fetches tag names of the given entries keyed by entry id.
+func (r *SQLLedgerRepository) loadEntryTags(ctx context.Context, entryIDs []uint64) (map[uint64][]string, error) {
+	out := make(map[uint64][]string)
+	if len(entryIDs) == 0 {
+		return out, nil
+	}
+
+	placeholders := make([]string, 0, len(entryIDs))
+	args := make([]interface{}, 0, len(entryIDs))
+	for _, id := range entryIDs {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	query := `
+SELECT et.entry_id, t.name
+FROM ledger_entry_tags et
+JOIN ledger_tags t ON t.id = et.tag_id
+WHERE et.entry_id IN (` + strings.Join(placeholders, ", ") + `)
+ORDER BY et.entry_id ASC, t.name ASC
+`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if deletedAt.Valid {
-		return ErrEntryAlreadyDeleted
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID uint64
+		var name string
+		if err := rows.Scan(&entryID, &name); err != nil {
+			return nil, err
+		}
+		out[entryID] = append(out[entryID], name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return ErrLedgerEntryNotFound
+	return out, nil
+}
+
+// loadEntryLines fetches the line items of the given entries keyed by entry id.
+func (r *SQLLedgerRepository) loadEntryLines(ctx context.Context, entryIDs []uint64) (map[uint64][]model.LedgerEntryLine, error) {
+	out := make(map[uint64][]model.LedgerEntryLine)
+	if len(entryIDs) == 0 {
+		return out, nil
+	}
+
+	placeholders := make([]string, 0, len(entryIDs))
+	args := make([]interface{}, 0, len(entryIDs))
+	for _, id := range entryIDs {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	query := listLedgerEntryLinesBaseSQL + " WHERE entry_id IN (" + strings.Join(placeholders, ", ") + ") ORDER BY entry_id ASC, line_no ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line model.LedgerEntryLine
+		if err := rows.Scan(&line.ID, &line.EntryID, &line.Description, &line.Amount, &line.Category); err != nil {
+			return nil, err
+		}
+		out[line.EntryID] = append(out[line.EntryID], line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
 }
 
 func (r *SQLLedgerRepository) GetEntryByID(ctx context.Context, entryID uint64) (model.LedgerEntry, error) {
@@ -176,13 +365,28 @@ func (r *SQLLedgerRepository) GetEntryByID(ctx context.Context, entryID uint64)
 		&entry.Amount,
 		&entry.OccurredAt,
 		&entry.Description,
+		&entry.Category,
 		&entry.MonthKey,
 		&entry.CreatedAt,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return model.LedgerEntry{}, ErrLedgerEntryNotFound
 	}
-	return entry, err
+	if err != nil {
+		return model.LedgerEntry{}, err
+	}
+
+	lines, err := r.loadEntryLines(ctx, []uint64{entry.ID})
+	if err != nil {
+		return model.LedgerEntry{}, err
+	}
+	entry.Lines = lines[entry.ID]
+	tags, err := r.loadEntryTags(ctx, []uint64{entry.ID})
+	if err != nil {
+		return model.LedgerEntry{}, err
+	}
+	entry.Tags = tags[entry.ID]
+	return entry, nil
 }
 
 func (r *SQLLedgerRepository) CreateEntryWithRequestID(ctx context.Context, input CreateLedgerEntryInput, requestID string) (uint64, bool, error) {
@@ -207,7 +411,7 @@ func (r *SQLLedgerRepository) CreateEntryWithRequestID(ctx context.Context, inpu
 		return existingID, true, nil
 	}
 
-	res, execErr := tx.ExecContext(ctx, insertLedgerEntrySQL, input.UserID, input.EntryType, input.Amount, input.OccurredAt, input.Description)
+	res, execErr := tx.ExecContext(ctx, insertLedgerEntrySQL, input.UserID, input.EntryType, input.Amount, input.OccurredAt, input.Description, input.Category)
 	if execErr != nil {
 		err = execErr
 		return 0, false, err
@@ -218,6 +422,13 @@ func (r *SQLLedgerRepository) CreateEntryWithRequestID(ctx context.Context, inpu
 		return 0, false, err
 	}
 
+	if err = insertEntryLines(ctx, tx, uint64(insertedID), input.Lines); err != nil {
+		return 0, false, err
+	}
+	if err = insertEntryTags(ctx, tx, uint64(insertedID), input.Tags); err != nil {
+		return 0, false, err
+	}
+
 	if _, err = tx.ExecContext(ctx, updateLedgerIdempotencyResultSQL, insertedID, requestID); err != nil {
 		return 0, false, err
 	}
@@ -299,18 +510,94 @@ func (r *SQLLedgerRepository) ListEntries(ctx context.Context, filter ListLedger
 	items := make([]model.LedgerEntry, 0)
 	for rows.Next() {
 		item := model.LedgerEntry{}
-		if err := rows.Scan(&item.ID, &item.UserID, &item.EntryType, &item.Amount, &item.OccurredAt, &item.Description, &item.MonthKey, &item.CreatedAt); err != nil {
+		var deletedAt sql.NullTime
+		var deletedBy sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.UserID, &item.EntryType, &item.Amount, &item.OccurredAt, &item.Description, &item.Category, &item.MonthKey, &item.CreatedAt, &deletedAt, &deletedBy, &item.RecorderName); err != nil {
 			return nil, err
 		}
+		if deletedAt.Valid {
+			item.DeletedAt = &deletedAt.Time
+		}
+		if deletedBy.Valid {
+			by := uint64(deletedBy.Int64)
+			item.DeletedBy = &by
+		}
 		items = append(items, item)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
+	ids := make([]uint64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	lines, err := r.loadEntryLines(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := r.loadEntryTags(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	openQuestions, err := r.loadOpenQuestionCounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Lines = lines[items[i].ID]
+		items[i].Tags = tags[items[i].ID]
+		items[i].OpenQuestions = openQuestions[items[i].ID]
+	}
+
 	return items, nil
 }
 
+// loadOpenQuestionCounts counts the visible, unresolved comment threads of
+// each entry.
+func (r *SQLLedgerRepository) loadOpenQuestionCounts(ctx context.Context, entryIDs []uint64) (map[uint64]int, error) {
+	out := make(map[uint64]int)
+	if len(entryIDs) == 0 {
+		return out, nil
+	}
+
+	placeholders := make([]string, 0, len(entryIDs))
+	args := make([]interface{}, 0, len(entryIDs))
+	for _, id := range entryIDs {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+	query := `
+SELECT entry_id, COUNT(1)
+FROM ledger_entry_comments
+WHERE entry_id IN (` + strings.Join(placeholders, ", ") + `)
+  AND parent_id IS NULL
+  AND resolved_at IS NULL
+  AND hidden = FALSE
+GROUP BY entry_id
+`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID uint64
+		var count int
+		if err := rows.Scan(&entryID, &count); err != nil {
+			return nil, err
+		}
+		out[entryID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
 func (r *SQLLedgerRepository) CountEntries(ctx context.Context, filter ListLedgerEntriesFilter) (int64, error) {
 	if err := filter.Validate(); err != nil {
 		return 0, err
@@ -349,12 +636,12 @@ WHERE month_key = ? AND deleted_at IS NULL
 	return out, nil
 }
 
-func (r *SQLLedgerRepository) ListMonthlyStatistics(ctx context.Context) ([]MonthlyStatistic, error) {
+func (r *SQLLedgerRepository) ListMonthlyStatistics(ctx context.Context, currentMonth string) ([]MonthlyStatistic, error) {
 	const statsSQL = `
 WITH RECURSIVE bounds AS (
 	SELECT
-		COALESCE(MIN(month_key), DATE_FORMAT(UTC_TIMESTAMP() + INTERVAL 8 HOUR, '%Y-%m')) AS start_month,
-		DATE_FORMAT(UTC_TIMESTAMP() + INTERVAL 8 HOUR, '%Y-%m') AS end_month
+		COALESCE(MIN(month_key), ?) AS start_month,
+		? AS end_month
 	FROM ledger_entries
 	WHERE deleted_at IS NULL
 ),
@@ -388,7 +675,7 @@ LEFT JOIN monthly_totals ON monthly_totals.month_key = months.month_key
 ORDER BY months.month_key DESC
 `
 
-	rows, err := r.db.QueryContext(ctx, statsSQL)
+	rows, err := r.db.QueryContext(ctx, statsSQL, currentMonth, currentMonth)
 	if err != nil {
 		return nil, err
 	}
@@ -424,6 +711,9 @@ func (f ListLedgerEntriesFilter) Validate() error {
 
 func buildLedgerFilterClause(filter ListLedgerEntriesFilter) (string, []interface{}) {
 	clauses := []string{"deleted_at IS NULL"}
+	if filter.Deleted {
+		clauses[0] = "deleted_at IS NOT NULL"
+	}
 	args := make([]interface{}, 0, 2)
 
 	if filter.MonthKey != "" {
@@ -434,6 +724,45 @@ func buildLedgerFilterClause(filter ListLedgerEntriesFilter) (string, []interfac
 		clauses = append(clauses, "entry_type = ?")
 		args = append(args, string(filter.Type))
 	}
+	if filter.Tag != "" {
+		clauses = append(clauses, "id IN (SELECT et.entry_id FROM ledger_entry_tags et JOIN ledger_tags t ON t.id = et.tag_id WHERE t.name = ?)")
+		args = append(args, filter.Tag)
+	}
 
 	return " WHERE " + strings.Join(clauses, " AND "), args
 }
+
+// ListTagUsage returns every tag in use on a live entry, most used first.
+func (r *SQLLedgerRepository) ListTagUsage(ctx context.Context) ([]TagUsage, error) {
+	const query = `
+SELECT
+  t.name,
+  COUNT(1) AS entry_count,
+  COALESCE(SUM(CASE WHEN e.entry_type = 'donation' THEN e.amount ELSE 0 END), 0) AS donation_total,
+  COALESCE(SUM(CASE WHEN e.entry_type = 'expense' THEN e.amount ELSE 0 END), 0) AS expense_total
+FROM ledger_tags t
+JOIN ledger_entry_tags et ON et.tag_id = t.id
+JOIN ledger_entries e ON e.id = et.entry_id AND e.deleted_at IS NULL
+GROUP BY t.id, t.name
+ORDER BY entry_count DESC, t.name ASC
+`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]TagUsage, 0)
+	for rows.Next() {
+		var item TagUsage
+		if err := rows.Scan(&item.Name, &item.EntryCount, &item.DonationTotal, &item.ExpenseTotal); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
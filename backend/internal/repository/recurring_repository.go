@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"propets/backend/internal/model"
+)
+
+type CreateRecurringTemplateInput struct {
+	EntryType  model.LedgerEntryType
+	Amount     model.Money
+	Donor      string
+	Purpose    string
+	HandledBy  string
+	Category   model.ExpenseCategory
+	Frequency  model.RecurrenceFrequency
+	DayOfMonth int
+	StartDate  time.Time
+	EndDate    *time.Time
+	CreatedBy  uint64
+}
+
+type UpdateRecurringTemplateInput struct {
+	ID      uint64
+	Active  bool
+	EndDate *time.Time
+}
+
+type RecurringRepository interface {
+	CreateTemplate(ctx context.Context, input CreateRecurringTemplateInput) (uint64, error)
+	GetTemplateByID(ctx context.Context, id uint64) (model.RecurringTemplate, error)
+	ListTemplates(ctx context.Context, activeOnly bool) ([]model.RecurringTemplate, error)
+	UpdateTemplate(ctx context.Context, input UpdateRecurringTemplateInput) error
+	DeleteTemplate(ctx context.Context, id uint64) error
+	SetTemplateLastPeriod(ctx context.Context, id uint64, period string) error
+}
+
+type SQLRecurringRepository struct {
+	db *sql.DB
+}
+
+func NewSQLRecurringRepository(db *sql.DB) *SQLRecurringRepository {
+	return &SQLRecurringRepository{db: db}
+}
+
+var ErrRecurringTemplateNotFound = errors.New("recurring template not found")
+
+const insertRecurringTemplateSQL = `
+INSERT INTO recurring_templates (entry_type, amount, donor, purpose, handled_by, category, frequency, day_of_month, start_date, end_date, created_by)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+const listRecurringTemplatesBaseSQL = `
+SELECT id, entry_type, amount, donor, purpose, handled_by, category, frequency, day_of_month, start_date, end_date, active, last_period, created_by, created_at
+FROM recurring_templates
+`
+
+func (r *SQLRecurringRepository) CreateTemplate(ctx context.Context, input CreateRecurringTemplateInput) (uint64, error) {
+	var endDate interface{}
+	if input.EndDate != nil {
+		endDate = *input.EndDate
+	}
+	res, err := r.db.ExecContext(
+		ctx,
+		insertRecurringTemplateSQL,
+		input.EntryType,
+		input.Amount,
+		input.Donor,
+		input.Purpose,
+		input.HandledBy,
+		input.Category,
+		input.Frequency,
+		input.DayOfMonth,
+		input.StartDate,
+		endDate,
+		input.CreatedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *SQLRecurringRepository) GetTemplateByID(ctx context.Context, id uint64) (model.RecurringTemplate, error) {
+	item, err := scanRecurringTemplate(r.db.QueryRowContext(ctx, listRecurringTemplatesBaseSQL+" WHERE id = ? LIMIT 1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.RecurringTemplate{}, ErrRecurringTemplateNotFound
+	}
+	return item, err
+}
+
+func (r *SQLRecurringRepository) ListTemplates(ctx context.Context, activeOnly bool) ([]model.RecurringTemplate, error) {
+	query := listRecurringTemplatesBaseSQL
+	if activeOnly {
+		query += " WHERE active = TRUE"
+	}
+	query += " ORDER BY id ASC"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.RecurringTemplate, 0)
+	for rows.Next() {
+		item, err := scanRecurringTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *SQLRecurringRepository) UpdateTemplate(ctx context.Context, input UpdateRecurringTemplateInput) error {
+	var endDate interface{}
+	if input.EndDate != nil {
+		endDate = *input.EndDate
+	}
+	res, err := r.db.ExecContext(ctx, `UPDATE recurring_templates SET active = ?, end_date = ? WHERE id = ?`, input.Active, endDate, input.ID)
+	if err != nil {
+		return err
+	}
+	return requireTemplateAffected(ctx, r, res, input.ID)
+}
+
+func (r *SQLRecurringRepository) DeleteTemplate(ctx context.Context, id uint64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM recurring_templates WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRecurringTemplateNotFound
+	}
+	return nil
+}
+
+func (r *SQLRecurringRepository) SetTemplateLastPeriod(ctx context.Context, id uint64, period string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE recurring_templates SET last_period = ? WHERE id = ?`, period, id)
+	return err
+}
+
+// requireTemplateAffected tells a missing template apart from an update that
+// left the row unchanged, which MySQL also reports as zero affected rows.
+func requireTemplateAffected(ctx context.Context, r *SQLRecurringRepository, res sql.Result, id uint64) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+	_, err = r.GetTemplateByID(ctx, id)
+	return err
+}
+
+func scanRecurringTemplate(row rowScanner) (model.RecurringTemplate, error) {
+	var item model.RecurringTemplate
+	var endDate sql.NullTime
+	var lastPeriod sql.NullString
+	if err := row.Scan(
+		&item.ID,
+		&item.EntryType,
+		&item.Amount,
+		&item.Donor,
+		&item.Purpose,
+		&item.HandledBy,
+		&item.Category,
+		&item.Frequency,
+		&item.DayOfMonth,
+		&item.StartDate,
+		&endDate,
+		&item.Active,
+		&lastPeriod,
+		&item.CreatedBy,
+		&item.CreatedAt,
+	); err != nil {
+		return model.RecurringTemplate{}, err
+	}
+	if endDate.Valid {
+		v := endDate.Time
+		item.EndDate = &v
+	}
+	item.LastPeriod = lastPeriod.String
+	return item, nil
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"propets/backend/internal/model"
+)
+
+type CreateReimbursementInput struct {
+	RequesterID uint64
+	Amount      model.Money
+	Purpose     string
+	Category    model.ExpenseCategory
+	OccurredAt  time.Time
+	ReceiptURL  string
+}
+
+type ListReimbursementsFilter struct {
+	RequesterID uint64
+	Status      model.ReimbursementStatus
+	Limit       int
+	Offset      int
+}
+
+type ReimbursementRepository interface {
+	CreateReimbursement(ctx context.Context, input CreateReimbursementInput) (uint64, error)
+	GetReimbursementByID(ctx context.Context, id uint64) (model.Reimbursement, error)
+	ListReimbursements(ctx context.Context, filter ListReimbursementsFilter) ([]model.Reimbursement, error)
+	CountReimbursements(ctx context.Context, filter ListReimbursementsFilter) (int64, error)
+	ClaimReimbursement(ctx context.Context, id, reviewerID uint64) (model.Reimbursement, error)
+	SetReimbursementEntry(ctx context.Context, id, entryID uint64) error
+	MarkReimbursementRejected(ctx context.Context, id, reviewerID uint64, note string) error
+}
+
+type SQLReimbursementRepository struct {
+	db *sql.DB
+}
+
+func NewSQLReimbursementRepository(db *sql.DB) *SQLReimbursementRepository {
+	return &SQLReimbursementRepository{db: db}
+}
+
+var (
+	ErrReimbursementNotFound   = errors.New("reimbursement not found")
+	ErrReimbursementNotPending = errors.New("reimbursement is not pending")
+)
+
+const insertReimbursementSQL = `
+INSERT INTO reimbursement_requests (requester_id, amount, purpose, category, occurred_at, receipt_url)
+VALUES (?, ?, ?, ?, ?, ?)
+`
+
+const listReimbursementsBaseSQL = `
+SELECT id, requester_id, amount, purpose, category, occurred_at, receipt_url, status, reviewed_by, reviewed_at, review_note, entry_id, created_at
+FROM reimbursement_requests
+`
+
+func (r *SQLReimbursementRepository) CreateReimbursement(ctx context.Context, input CreateReimbursementInput) (uint64, error) {
+	res, err := r.db.ExecContext(ctx, insertReimbursementSQL, input.RequesterID, input.Amount, input.Purpose, input.Category, input.OccurredAt, input.ReceiptURL)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+func (r *SQLReimbursementRepository) GetReimbursementByID(ctx context.Context, id uint64) (model.Reimbursement, error) {
+	item, err := scanReimbursement(r.db.QueryRowContext(ctx, listReimbursementsBaseSQL+" WHERE id = ? LIMIT 1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Reimbursement{}, ErrReimbursementNotFound
+	}
+	return item, err
+}
+
+func (r *SQLReimbursementRepository) ListReimbursements(ctx context.Context, filter ListReimbursementsFilter) ([]model.Reimbursement, error) {
+	whereSQL, args := buildReimbursementFilterClause(filter)
+	query := listReimbursementsBaseSQL + whereSQL + " ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.Reimbursement, 0)
+	for rows.Next() {
+		item, err := scanReimbursement(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *SQLReimbursementRepository) CountReimbursements(ctx context.Context, filter ListReimbursementsFilter) (int64, error) {
+	whereSQL, args := buildReimbursementFilterClause(filter)
+	var total int64
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(1) FROM reimbursement_requests"+whereSQL, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// ClaimReimbursement moves a pending request to approved by reviewerID
+// before its expense is booked, so a concurrent approval or rejection of the
+// same request fails instead of racing the booking. A request that was
+// claimed but has no entry yet is returned as is, so an interrupted approval
+// can be finished by retrying it.
+func (r *SQLReimbursementRepository) ClaimReimbursement(ctx context.Context, id, reviewerID uint64) (model.Reimbursement, error) {
+	const claimSQL = `
+UPDATE reimbursement_requests
+SET status = 'approved', reviewed_by = ?, reviewed_at = NOW()
+WHERE id = ? AND status = 'pending'
+`
+	res, err := r.db.ExecContext(ctx, claimSQL, reviewerID, id)
+	if err != nil {
+		return model.Reimbursement{}, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return model.Reimbursement{}, err
+	}
+
+	current, err := r.GetReimbursementByID(ctx, id)
+	if err != nil {
+		return model.Reimbursement{}, err
+	}
+	if affected == 0 && current.Status != model.ReimbursementStatusUnbooked {
+		return model.Reimbursement{}, ErrReimbursementNotPending
+	}
+	return current, nil
+}
+
+// SetReimbursementEntry records the expense booked for a claimed request.
+// Setting the same entry again is a no-op so a retried approval stays
+// idempotent.
+func (r *SQLReimbursementRepository) SetReimbursementEntry(ctx context.Context, id, entryID uint64) error {
+	const setEntrySQL = `
+UPDATE reimbursement_requests
+SET entry_id = ?
+WHERE id = ? AND status = 'approved' AND (entry_id IS NULL OR entry_id = ?)
+`
+	res, err := r.db.ExecContext(ctx, setEntrySQL, entryID, id, entryID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	current, err := r.GetReimbursementByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.EntryID != nil && *current.EntryID == entryID {
+		return nil
+	}
+	return ErrReimbursementNotPending
+}
+
+func (r *SQLReimbursementRepository) MarkReimbursementRejected(ctx context.Context, id, reviewerID uint64, note string) error {
+	const rejectSQL = `
+UPDATE reimbursement_requests
+SET status = 'rejected', reviewed_by = ?, reviewed_at = NOW(), review_note = ?
+WHERE id = ? AND status = 'pending'
+`
+	res, err := r.db.ExecContext(ctx, rejectSQL, reviewerID, note, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	if _, err := r.GetReimbursementByID(ctx, id); err != nil {
+		return err
+	}
+	return ErrReimbursementNotPending
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReimbursement(row rowScanner) (model.Reimbursement, error) {
+	var item model.Reimbursement
+	var reviewedBy, entryID sql.NullInt64
+	var reviewedAt sql.NullTime
+	if err := row.Scan(
+		&item.ID,
+		&item.RequesterID,
+		&item.Amount,
+		&item.Purpose,
+		&item.Category,
+		&item.OccurredAt,
+		&item.ReceiptURL,
+		&item.Status,
+		&reviewedBy,
+		&reviewedAt,
+		&item.ReviewNote,
+		&entryID,
+		&item.CreatedAt,
+	); err != nil {
+		return model.Reimbursement{}, err
+	}
+	if reviewedBy.Valid {
+		v := uint64(reviewedBy.Int64)
+		item.ReviewedBy = &v
+	}
+	if reviewedAt.Valid {
+		v := reviewedAt.Time
+		item.ReviewedAt = &v
+	}
+	if entryID.Valid {
+		v := uint64(entryID.Int64)
+		item.EntryID = &v
+	} else if item.Status == model.ReimbursementStatusApproved {
+		item.Status = model.ReimbursementStatusUnbooked
+	}
+	return item, nil
+}
+
+func buildReimbursementFilterClause(filter ListReimbursementsFilter) (string, []interface{}) {
+	clauses := make([]string, 0, 2)
+	args := make([]interface{}, 0, 2)
+
+	if filter.RequesterID != 0 {
+		clauses = append(clauses, "requester_id = ?")
+		args = append(args, filter.RequesterID)
+	}
+	switch filter.Status {
+	case "":
+	case model.ReimbursementStatusApproved:
+		clauses = append(clauses, "status = 'approved' AND entry_id IS NOT NULL")
+	case model.ReimbursementStatusUnbooked:
+		clauses = append(clauses, "status = 'approved' AND entry_id IS NULL")
+	default:
+		clauses = append(clauses, "status = ?")
+		args = append(args, string(filter.Status))
+	}
+	if len(clauses) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
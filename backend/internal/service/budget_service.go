@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+type BudgetService struct {
+	budgets repository.BudgetRepository
+	ledger  repository.LedgerRepository
+}
+
+type CategoryBudget struct {
+	Category      string      `json:"category"`
+	MonthlyAmount model.Money `json:"monthly_amount"`
+	UpdatedBy     uint64      `json:"updated_by"`
+	UpdatedAt     string      `json:"updated_at"`
+}
+
+type SetBudgetInput struct {
+	ActorUserID   uint64
+	Category      string
+	MonthlyAmount string
+}
+
+type CategoryBudgetSummary struct {
+	Category   string      `json:"category"`
+	HasBudget  bool        `json:"has_budget"`
+	Budget     model.Money `json:"budget"`
+	Actual     model.Money `json:"actual"`
+	Variance   model.Money `json:"variance"`
+	OverBudget bool        `json:"over_budget"`
+}
+
+type BudgetWarning struct {
+	Category string      `json:"category"`
+	Month    string      `json:"month"`
+	Budget   model.Money `json:"budget"`
+	Actual   model.Money `json:"actual"`
+	Variance model.Money `json:"variance"`
+	Message  string      `json:"message"`
+}
+
+func NewBudgetService(budgets repository.BudgetRepository, ledger repository.LedgerRepository) *BudgetService {
+	return &BudgetService{budgets: budgets, ledger: ledger}
+}
+
+func (s *BudgetService) ListBudgets(ctx context.Context) ([]CategoryBudget, error) {
+	budgets, err := s.budgets.ListBudgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]CategoryBudget, 0, len(budgets))
+	for _, budget := range budgets {
+		items = append(items, CategoryBudget{
+			Category:      string(budget.Category),
+			MonthlyAmount: budget.MonthlyAmount,
+			UpdatedBy:     budget.UpdatedBy,
+			UpdatedAt:     budget.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return items, nil
+}
+
+func (s *BudgetService) SetBudget(ctx context.Context, input SetBudgetInput) error {
+	category, err := model.ParseExpenseCategory(input.Category)
+	if err != nil {
+		return err
+	}
+	amount, err := model.ParseAmount(input.MonthlyAmount)
+	if err != nil {
+		return err
+	}
+
+	return s.budgets.UpsertBudget(ctx, repository.UpsertCategoryBudgetInput{
+		Category:      category,
+		MonthlyAmount: amount,
+		UpdatedBy:     input.ActorUserID,
+	})
+}
+
+func (s *BudgetService) DeleteBudget(ctx context.Context, rawCategory string) error {
+	category, err := model.ParseExpenseCategory(rawCategory)
+	if err != nil {
+		return err
+	}
+	return s.budgets.DeleteBudget(ctx, category)
+}
+
+func (s *BudgetService) MonthlyBudgetReport(ctx context.Context, month string) ([]CategoryBudgetSummary, error) {
+	month = strings.TrimSpace(month)
+	if err := validateMonth(month); err != nil {
+		return nil, err
+	}
+
+	usage, err := s.budgets.ListCategoryBudgetUsage(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]CategoryBudgetSummary, 0, len(usage))
+	for _, item := range usage {
+		items = append(items, CategoryBudgetSummary{
+			Category:   string(item.Category),
+			HasBudget:  item.HasBudget,
+			Budget:     item.Budget,
+			Actual:     item.Actual,
+			Variance:   item.Variance,
+			OverBudget: item.OverBudget,
+		})
+	}
+	return items, nil
+}
+
+// ExpenseBudgetWarnings reports every category the given expense pushes over
+// its monthly budget: the category was within budget before the expense and
+// is over it once the expense is counted. Categories that were already over
+// do not warn again. Split expenses are checked per line category. Entries
+// that are not expenses yield no warnings.
+func (s *BudgetService) ExpenseBudgetWarnings(ctx context.Context, entryID uint64) ([]BudgetWarning, error) {
+	entry, err := s.ledger.GetEntryByID(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.EntryType != model.LedgerEntryTypeExpense {
+		return nil, nil
+	}
+
+	shares := expenseCategoryShares(entry)
+	usage, err := s.budgets.ListCategoryBudgetUsage(ctx, entry.MonthKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []BudgetWarning
+	for _, item := range usage {
+		share, ok := shares[item.Category]
+		if !ok || !pushesOverBudget(item, share) {
+			continue
+		}
+		warnings = append(warnings, BudgetWarning{
+			Category: string(item.Category),
+			Month:    entry.MonthKey,
+			Budget:   item.Budget,
+			Actual:   item.Actual,
+			Variance: item.Variance,
+			Message:  fmt.Sprintf("category %s is over budget for %s: spent %s of %s", item.Category, entry.MonthKey, item.Actual, item.Budget),
+		})
+	}
+	return warnings, nil
+}
+
+// expenseCategoryShares sums an expense per category it is counted under.
+func expenseCategoryShares(entry model.LedgerEntry) map[model.ExpenseCategory]model.Money {
+	if len(entry.Lines) == 0 {
+		return map[model.ExpenseCategory]model.Money{entry.Category: entry.Amount}
+	}
+	shares := make(map[model.ExpenseCategory]model.Money, len(entry.Lines))
+	for _, line := range entry.Lines {
+		shares[entry.LineCategory(line)] += line.Amount
+	}
+	return shares
+}
+
+// pushesOverBudget reports whether share is what took the category from
+// within its budget to over it.
+func pushesOverBudget(item repository.CategoryBudgetUsage, share model.Money) bool {
+	return item.HasBudget && item.Actual-share <= item.Budget && item.Budget < item.Actual
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+func TestPushesOverBudget(t *testing.T) {
+	cases := []struct {
+		name                  string
+		hasBudget             bool
+		budget, actual, share model.Money
+		want                  bool
+	}{
+		{"stays within budget", true, 10000, 8000, 3000, false},
+		{"lands exactly on budget", true, 10000, 10000, 3000, false},
+		{"crosses the budget", true, 10000, 12000, 3000, true},
+		{"starts exactly on budget", true, 10000, 13000, 3000, true},
+		{"already over before", true, 10000, 15000, 3000, false},
+		{"no budget set", false, 0, 15000, 3000, false},
+	}
+	for _, tc := range cases {
+		item := repository.CategoryBudgetUsage{HasBudget: tc.hasBudget, Budget: tc.budget, Actual: tc.actual}
+		if got := pushesOverBudget(item, tc.share); got != tc.want {
+			t.Errorf("%s: pushesOverBudget = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExpenseCategorySharesSplitsLines(t *testing.T) {
+	entry := model.LedgerEntry{
+		Amount:   9000,
+		Category: model.ExpenseCategoryMedical,
+		Lines: []model.LedgerEntryLine{
+			{Amount: 5000},
+			{Amount: 1000, Category: model.ExpenseCategoryFood},
+			{Amount: 3000, Category: model.ExpenseCategoryFood},
+		},
+	}
+	shares := expenseCategoryShares(entry)
+	if len(shares) != 2 || shares[model.ExpenseCategoryMedical] != 5000 || shares[model.ExpenseCategoryFood] != 4000 {
+		t.Errorf("shares = %v", shares)
+	}
+
+	whole := expenseCategoryShares(model.LedgerEntry{Amount: 700, Category: model.ExpenseCategoryOther})
+	if len(whole) != 1 || whole[model.ExpenseCategoryOther] != 700 {
+		t.Errorf("shares without lines = %v", whole)
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+const maxCommentBodyLen = 1000
+
+var ErrCommentReplyForbidden = errors.New("only ledger keepers and the thread author can reply")
+
+// CommentService runs the question threads members open on ledger entries.
+type CommentService struct {
+	repo    repository.CommentRepository
+	entries repository.LedgerRepository
+}
+
+type PostCommentInput struct {
+	EntryID  uint64
+	AuthorID uint64
+	// Keeper is set for users who keep the ledger; they answer questions and
+	// can reply in any thread.
+	Keeper bool
+	// ParentID is the comment being replied to; zero opens a new thread.
+	ParentID uint64
+	Body     string
+}
+
+type ModerateCommentInput struct {
+	CommentID uint64
+	ActorID   uint64
+	Resolved  *bool
+	Hidden    *bool
+}
+
+func NewCommentService(repo repository.CommentRepository, entries repository.LedgerRepository) *CommentService {
+	return &CommentService{repo: repo, entries: entries}
+}
+
+// List returns the comments of an entry in posting order. Hidden comments are
+// only included for admins.
+func (s *CommentService) List(ctx context.Context, entryID uint64, includeHidden bool) ([]model.LedgerComment, error) {
+	if _, err := s.entries.GetEntryByID(ctx, entryID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListComments(ctx, entryID, includeHidden)
+}
+
+func (s *CommentService) Post(ctx context.Context, input PostCommentInput) (uint64, error) {
+	body := strings.TrimSpace(input.Body)
+	if body == "" {
+		return 0, errors.New("body is required")
+	}
+	if len([]rune(body)) > maxCommentBodyLen {
+		return 0, fmt.Errorf("invalid body: at most %d characters", maxCommentBodyLen)
+	}
+	if _, err := s.entries.GetEntryByID(ctx, input.EntryID); err != nil {
+		return 0, err
+	}
+
+	var thread *model.LedgerComment
+	if input.ParentID != 0 {
+		parent, err := s.repo.GetCommentByID(ctx, input.ParentID)
+		if errors.Is(err, repository.ErrCommentNotFound) {
+			return 0, errors.New("invalid parentId")
+		}
+		if err != nil {
+			return 0, err
+		}
+		if parent.EntryID != input.EntryID {
+			return 0, errors.New("invalid parentId: comment belongs to another entry")
+		}
+		// Replies always hang off the thread, never off another reply.
+		if parent.ParentID != nil {
+			parent, err = s.repo.GetCommentByID(ctx, *parent.ParentID)
+			if err != nil {
+				return 0, err
+			}
+		}
+		if !input.Keeper && parent.AuthorID != input.AuthorID {
+			return 0, ErrCommentReplyForbidden
+		}
+		thread = &parent
+	}
+
+	create := repository.CreateCommentInput{
+		EntryID:  input.EntryID,
+		AuthorID: input.AuthorID,
+		Body:     body,
+	}
+	if thread != nil {
+		create.ParentID = &thread.ID
+	}
+	id, err := s.repo.CreateComment(ctx, create)
+	if err != nil {
+		return 0, err
+	}
+
+	// A follow-up from the asker means the answer did not settle it.
+	if thread != nil && !input.Keeper && thread.ResolvedAt != nil {
+		if err := s.repo.SetThreadResolved(ctx, thread.ID, nil); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+func (s *CommentService) Moderate(ctx context.Context, input ModerateCommentInput) error {
+	comment, err := s.repo.GetCommentByID(ctx, input.CommentID)
+	if err != nil {
+		return err
+	}
+	if input.Resolved == nil && input.Hidden == nil {
+		return errors.New("resolved or hidden is required")
+	}
+
+	if input.Resolved != nil {
+		if comment.ParentID != nil {
+			return errors.New("invalid resolved: only threads can be resolved")
+		}
+		var by *uint64
+		if *input.Resolved {
+			by = &input.ActorID
+		}
+		if err := s.repo.SetThreadResolved(ctx, comment.ID, by); err != nil {
+			return err
+		}
+	}
+	if input.Hidden != nil {
+		var by *uint64
+		if *input.Hidden {
+			by = &input.ActorID
+		}
+		if err := s.repo.SetCommentHidden(ctx, comment.ID, by); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+const (
+	maxDonorNameLen = 64
+	maxGroupNameLen = 32
+)
+
+var ErrInvalidDonationReportStatus = errors.New("invalid status, expected pending, confirmed, unbooked or rejected")
+
+// DonationReportService keeps member-reported donations in a separate queue
+// until an admin confirms them, so they never reach the ledger totals early.
+type DonationReportService struct {
+	repo   repository.DonationReportRepository
+	ledger *LedgerService
+}
+
+type SubmitDonationReportInput struct {
+	ReporterID    uint64
+	Donor         string
+	Group         string
+	Amount        string
+	DonatedAt     string
+	ScreenshotURL string
+}
+
+type ListDonationReportsInput struct {
+	// ReporterID limits the listing to one member's reports; zero lists all.
+	ReporterID uint64
+	Status     string
+	Page       int
+	PageSize   int
+}
+
+type ListDonationReportsResult struct {
+	Items      []model.DonationReport
+	Page       int
+	PageSize   int
+	Total      int64
+	TotalPages int
+}
+
+func NewDonationReportService(repo repository.DonationReportRepository, ledger *LedgerService) *DonationReportService {
+	return &DonationReportService{repo: repo, ledger: ledger}
+}
+
+func (s *DonationReportService) Submit(ctx context.Context, input SubmitDonationReportInput) (uint64, error) {
+	donor := strings.TrimSpace(input.Donor)
+	if donor == "" {
+		return 0, errors.New("donor is required")
+	}
+	if len([]rune(donor)) > maxDonorNameLen {
+		return 0, fmt.Errorf("invalid donor: at most %d characters", maxDonorNameLen)
+	}
+	group := strings.TrimSpace(input.Group)
+	if len([]rune(group)) > maxGroupNameLen {
+		return 0, fmt.Errorf("invalid group: at most %d characters", maxGroupNameLen)
+	}
+	amount, err := model.ParseAmount(input.Amount)
+	if err != nil {
+		return 0, err
+	}
+	donatedAt, err := parseOccurredAt(input.DonatedAt, s.ledger.loc)
+	if err != nil {
+		return 0, fmt.Errorf("invalid donatedAt: %w", err)
+	}
+	screenshotURL, err := normalizeAttachmentURL(input.ScreenshotURL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid screenshotUrl: %w", err)
+	}
+
+	return s.repo.CreateDonationReport(ctx, repository.CreateDonationReportInput{
+		ReporterID:    input.ReporterID,
+		Donor:         donor,
+		GroupName:     group,
+		Amount:        amount,
+		DonatedAt:     donatedAt,
+		ScreenshotURL: screenshotURL,
+	})
+}
+
+func (s *DonationReportService) Get(ctx context.Context, id uint64) (model.DonationReport, error) {
+	return s.repo.GetDonationReportByID(ctx, id)
+}
+
+func (s *DonationReportService) List(ctx context.Context, input ListDonationReportsInput) (ListDonationReportsResult, error) {
+	page, pageSize, err := normalizePagination(input.Page, input.PageSize)
+	if err != nil {
+		return ListDonationReportsResult{}, err
+	}
+	status := model.DonationReportStatus(strings.ToLower(strings.TrimSpace(input.Status)))
+	switch status {
+	case "", model.DonationReportStatusPending, model.DonationReportStatusConfirmed, model.DonationReportStatusUnbooked, model.DonationReportStatusRejected:
+	default:
+		return ListDonationReportsResult{}, ErrInvalidDonationReportStatus
+	}
+
+	filter := repository.ListDonationReportsFilter{
+		ReporterID: input.ReporterID,
+		Status:     status,
+		Limit:      pageSize,
+		Offset:     (page - 1) * pageSize,
+	}
+	items, err := s.repo.ListDonationReports(ctx, filter)
+	if err != nil {
+		return ListDonationReportsResult{}, err
+	}
+	total, err := s.repo.CountDonationReports(ctx, filter)
+	if err != nil {
+		return ListDonationReportsResult{}, err
+	}
+
+	return ListDonationReportsResult{
+		Items:      items,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// Confirm claims the report for the reviewer and then books the reported
+// donation, so a rejection racing the confirmation cannot leave a donation
+// booked for a rejected report. The donation is booked under the admin who
+// claimed the report with the report id as its idempotency key, so retrying
+// the confirmation of a report left unbooked reuses any donation created the
+// first time.
+func (s *DonationReportService) Confirm(ctx context.Context, id, reviewerID uint64) (uint64, error) {
+	item, err := s.repo.ClaimDonationReport(ctx, id, reviewerID)
+	if err != nil {
+		return 0, err
+	}
+	if item.ReviewedBy != nil {
+		reviewerID = *item.ReviewedBy
+	}
+
+	donor := item.Donor
+	if item.GroupName != "" {
+		donor = item.GroupName + "，" + item.Donor
+	}
+	entryID, _, err := s.ledger.CreateDonation(ctx, DonationInput{
+		ActorUserID: reviewerID,
+		Donor:       donor,
+		DonatedAt:   item.DonatedAt.Format(time.RFC3339),
+		Amount:      item.Amount.String(),
+		RequestID:   fmt.Sprintf("donation-report-%d", item.ID),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.repo.SetDonationReportEntry(ctx, item.ID, entryID); err != nil {
+		return 0, err
+	}
+	return entryID, nil
+}
+
+func (s *DonationReportService) Reject(ctx context.Context, id, reviewerID uint64, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("reason is required")
+	}
+	return s.repo.MarkDonationReportRejected(ctx, id, reviewerID, reason)
+}
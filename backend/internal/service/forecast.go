@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"propets/backend/internal/model"
+)
+
+const (
+	defaultForecastMonths = 6
+	maxForecastMonths     = 36
+	defaultForecastWindow = 6
+	maxForecastWindow     = 60
+)
+
+var (
+	ErrInvalidForecastMonths = errors.New("months must be between 1 and 36")
+	ErrInvalidForecastWindow = errors.New("window must be between 1 and 60")
+)
+
+type ForecastInput struct {
+	Months   int
+	Window   int
+	Seasonal bool
+}
+
+type ForecastMonth struct {
+	Month             string      `json:"month"`
+	ProjectedDonation model.Money `json:"projected_donation"`
+	ProjectedExpense  model.Money `json:"projected_expense"`
+	ProjectedBalance  model.Money `json:"projected_balance"`
+}
+
+type BalanceForecast struct {
+	CurrentMonth    string          `json:"current_month"`
+	StartingBalance model.Money     `json:"starting_balance"`
+	Window          int             `json:"window"`
+	WindowMonths    int             `json:"window_months"`
+	Seasonal        bool            `json:"seasonal"`
+	AverageDonation model.Money     `json:"average_donation"`
+	AverageExpense  model.Money     `json:"average_expense"`
+	AverageNet      model.Money     `json:"average_net"`
+	Items           []ForecastMonth `json:"items"`
+	DepletionMonth  *string         `json:"depletion_month"`
+}
+
+type monthTotals struct {
+	month    time.Time
+	donation model.Money
+	expense  model.Money
+	balance  model.Money
+}
+
+// ForecastBalance projects the balance forward from trailing monthly averages.
+// The current month is still open, so it only provides the starting balance
+// and is left out of the averages. With seasonal enabled each projected month
+// is shifted by how far that calendar month historically deviates from the
+// overall monthly mean, which captures the new-year donation spike.
+func (s *LedgerQueryService) ForecastBalance(ctx context.Context, input ForecastInput) (BalanceForecast, error) {
+	months := input.Months
+	if months == 0 {
+		months = defaultForecastMonths
+	}
+	if months < 1 || months > maxForecastMonths {
+		return BalanceForecast{}, ErrInvalidForecastMonths
+	}
+	window := input.Window
+	if window == 0 {
+		window = defaultForecastWindow
+	}
+	if window < 1 || window > maxForecastWindow {
+		return BalanceForecast{}, ErrInvalidForecastWindow
+	}
+
+	stats, err := s.ListMonthlyStatistics(ctx)
+	if err != nil {
+		return BalanceForecast{}, err
+	}
+	history, err := parseMonthlyHistory(stats)
+	if err != nil {
+		return BalanceForecast{}, err
+	}
+	if len(history) == 0 {
+		return BalanceForecast{}, errors.New("no monthly statistics available")
+	}
+
+	current := history[len(history)-1]
+	completed := history[:len(history)-1]
+	trailing := completed
+	if len(trailing) > window {
+		trailing = trailing[len(trailing)-window:]
+	}
+
+	var sumDonation, sumExpense model.Money
+	for _, m := range trailing {
+		sumDonation += m.donation
+		sumExpense += m.expense
+	}
+	avgDonation := averageMoney(sumDonation, len(trailing))
+	avgExpense := averageMoney(sumExpense, len(trailing))
+
+	var donationIndex, expenseIndex map[time.Month]model.Money
+	if input.Seasonal {
+		donationIndex, expenseIndex = seasonalAdjustments(completed)
+	}
+
+	out := BalanceForecast{
+		CurrentMonth:    current.month.Format("2006-01"),
+		StartingBalance: current.balance,
+		Window:          window,
+		WindowMonths:    len(trailing),
+		Seasonal:        input.Seasonal,
+		AverageDonation: avgDonation,
+		AverageExpense:  avgExpense,
+		AverageNet:      avgDonation - avgExpense,
+		Items:           make([]ForecastMonth, 0, months),
+	}
+
+	balance := current.balance
+	for i := 1; i <= months; i++ {
+		month := current.month.AddDate(0, i, 0)
+		donation := max(avgDonation+donationIndex[month.Month()], 0)
+		expense := max(avgExpense+expenseIndex[month.Month()], 0)
+		balance += donation - expense
+
+		key := month.Format("2006-01")
+		out.Items = append(out.Items, ForecastMonth{
+			Month:             key,
+			ProjectedDonation: donation,
+			ProjectedExpense:  expense,
+			ProjectedBalance:  balance,
+		})
+		if balance <= 0 && out.DepletionMonth == nil {
+			out.DepletionMonth = &key
+		}
+	}
+
+	// Beyond the horizon fall back to a straight-line estimate on the trailing net.
+	net := avgDonation - avgExpense
+	if out.DepletionMonth == nil && net < 0 && balance > 0 {
+		deficit := -net
+		extra := int((balance + deficit - 1) / deficit)
+		key := current.month.AddDate(0, months+extra, 0).Format("2006-01")
+		out.DepletionMonth = &key
+	}
+
+	return out, nil
+}
+
+func parseMonthlyHistory(stats []MonthlyStatistic) ([]monthTotals, error) {
+	history := make([]monthTotals, 0, len(stats))
+	// Statistics are returned newest first; the forecast walks oldest first.
+	for i := len(stats) - 1; i >= 0; i-- {
+		stat := stats[i]
+		month, err := time.Parse("2006-01", stat.Month)
+		if err != nil {
+			return nil, fmt.Errorf("invalid statistics month %q: %w", stat.Month, err)
+		}
+		history = append(history, monthTotals{
+			month:    month,
+			donation: stat.DonationTotal,
+			expense:  stat.ExpenseTotal,
+			balance:  stat.CumulativeBalance,
+		})
+	}
+	return history, nil
+}
+
+// seasonalAdjustments returns, per calendar month, the difference between that
+// month's historical mean and the mean over all months.
+func seasonalAdjustments(history []monthTotals) (map[time.Month]model.Money, map[time.Month]model.Money) {
+	donation := make(map[time.Month]model.Money)
+	expense := make(map[time.Month]model.Money)
+	if len(history) == 0 {
+		return donation, expense
+	}
+
+	var totalDonation, totalExpense model.Money
+	sumDonation := make(map[time.Month]model.Money)
+	sumExpense := make(map[time.Month]model.Money)
+	counts := make(map[time.Month]int)
+	for _, m := range history {
+		totalDonation += m.donation
+		totalExpense += m.expense
+		sumDonation[m.month.Month()] += m.donation
+		sumExpense[m.month.Month()] += m.expense
+		counts[m.month.Month()]++
+	}
+	meanDonation := averageMoney(totalDonation, len(history))
+	meanExpense := averageMoney(totalExpense, len(history))
+
+	for month, n := range counts {
+		donation[month] = averageMoney(sumDonation[month], n) - meanDonation
+		expense[month] = averageMoney(sumExpense[month], n) - meanExpense
+	}
+	return donation, expense
+}
+
+// averageMoney divides to the nearest fen, rounding halves away from zero.
+func averageMoney(total model.Money, n int) model.Money {
+	if n == 0 {
+		return 0
+	}
+	d := model.Money(n)
+	if total < 0 {
+		return -((-total + d/2) / d)
+	}
+	return (total + d/2) / d
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+// statsRepository serves fixed monthly statistics; other methods are not
+// used by the forecast.
+type statsRepository struct {
+	repository.LedgerRepository
+	stats []repository.MonthlyStatistic
+}
+
+func (r statsRepository) ListMonthlyStatistics(context.Context, string) ([]repository.MonthlyStatistic, error) {
+	return r.stats, nil
+}
+
+type monthFlow struct {
+	month             string
+	donation, expense model.Money
+}
+
+// forecastService builds statistics from months listed oldest first; the
+// last one is the current month.
+func forecastService(flows ...monthFlow) *LedgerQueryService {
+	stats := make([]repository.MonthlyStatistic, len(flows))
+	var balance model.Money
+	for i, flow := range flows {
+		balance += flow.donation - flow.expense
+		stats[len(flows)-1-i] = repository.MonthlyStatistic{
+			MonthKey:          flow.month,
+			DonationTotal:     flow.donation,
+			ExpenseTotal:      flow.expense,
+			CumulativeBalance: balance,
+		}
+	}
+	return NewLedgerQueryService(statsRepository{stats: stats}, time.UTC)
+}
+
+func TestForecastBalance(t *testing.T) {
+	cases := []struct {
+		name            string
+		flows           []monthFlow
+		input           ForecastInput
+		wantWindow      int
+		wantDonation    model.Money
+		wantExpense     model.Money
+		wantFirstBal    model.Money
+		wantDepletion   string
+		wantNoDepletion bool
+	}{
+		{
+			name: "trailing window average",
+			flows: []monthFlow{
+				{"2024-01", 10000, 20000}, {"2024-02", 20000, 20000}, {"2024-03", 30000, 20000},
+				{"2024-04", 40000, 20000}, {"2024-05", 50000, 20000}, {"2024-06", 60000, 20000},
+				{"2024-07", 0, 0},
+			},
+			input:           ForecastInput{Months: 3, Window: 3},
+			wantWindow:      3,
+			wantDonation:    50000,
+			wantExpense:     20000,
+			wantFirstBal:    120000,
+			wantNoDepletion: true,
+		},
+		{
+			name:            "history shorter than the window",
+			flows:           []monthFlow{{"2024-01", 10000, 5000}, {"2024-02", 30000, 7000}, {"2024-03", 0, 1000}},
+			input:           ForecastInput{Months: 2, Window: 6},
+			wantWindow:      2,
+			wantDonation:    20000,
+			wantExpense:     6000,
+			wantFirstBal:    41000,
+			wantNoDepletion: true,
+		},
+		{
+			name:          "runs out within the horizon",
+			flows:         []monthFlow{{"2024-01", 50000, 0}, {"2024-02", 0, 20000}, {"2024-03", 0, 0}},
+			input:         ForecastInput{Months: 6, Window: 1},
+			wantWindow:    1,
+			wantDonation:  0,
+			wantExpense:   20000,
+			wantFirstBal:  10000,
+			wantDepletion: "2024-05",
+		},
+		{
+			name:          "runs out beyond the horizon",
+			flows:         []monthFlow{{"2024-01", 100000, 0}, {"2024-02", 10000, 30000}, {"2024-03", 0, 0}},
+			input:         ForecastInput{Months: 2, Window: 1},
+			wantWindow:    1,
+			wantDonation:  10000,
+			wantExpense:   30000,
+			wantFirstBal:  60000,
+			wantDepletion: "2024-07",
+		},
+	}
+	for _, tc := range cases {
+		got, err := forecastService(tc.flows...).ForecastBalance(context.Background(), tc.input)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got.WindowMonths != tc.wantWindow || got.AverageDonation != tc.wantDonation || got.AverageExpense != tc.wantExpense {
+			t.Errorf("%s: window %d, averages %s/%s; want %d, %s/%s", tc.name,
+				got.WindowMonths, got.AverageDonation, got.AverageExpense, tc.wantWindow, tc.wantDonation, tc.wantExpense)
+		}
+		if len(got.Items) != tc.input.Months || got.Items[0].ProjectedBalance != tc.wantFirstBal {
+			t.Errorf("%s: items = %+v, want %d starting at balance %s", tc.name, got.Items, tc.input.Months, tc.wantFirstBal)
+		}
+		switch {
+		case tc.wantNoDepletion && got.DepletionMonth != nil:
+			t.Errorf("%s: depletion month = %s, want none", tc.name, *got.DepletionMonth)
+		case tc.wantDepletion != "" && (got.DepletionMonth == nil || *got.DepletionMonth != tc.wantDepletion):
+			t.Errorf("%s: depletion month = %v, want %s", tc.name, got.DepletionMonth, tc.wantDepletion)
+		}
+	}
+}
+
+func TestForecastBalanceSeasonalNewYear(t *testing.T) {
+	// A year where January donations, around 新年, are far above the rest.
+	var flows []monthFlow
+	for m := 1; m <= 12; m++ {
+		donation := model.Money(20000)
+		if m == 1 {
+			donation = 130000
+		}
+		flows = append(flows, monthFlow{time.Date(2023, time.Month(m), 1, 0, 0, 0, 0, time.UTC).Format("2006-01"), donation, 10000})
+	}
+	flows = append(flows, monthFlow{"2024-01", 0, 0})
+	svc := forecastService(flows...)
+
+	flat, err := svc.ForecastBalance(context.Background(), ForecastInput{Months: 12, Window: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seasonal, err := svc.ForecastBalance(context.Background(), ForecastInput{Months: 12, Window: 12, Seasonal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 350000 over 12 months rounds to 29167 a month.
+	if flat.Items[11].Month != "2025-01" || flat.Items[11].ProjectedDonation != 29167 {
+		t.Errorf("flat January = %+v, want 29167", flat.Items[11])
+	}
+	if seasonal.Items[11].ProjectedDonation != 130000 {
+		t.Errorf("seasonal January = %+v, want 130000", seasonal.Items[11])
+	}
+	if seasonal.Items[0].Month != "2024-02" || seasonal.Items[0].ProjectedDonation != 20000 {
+		t.Errorf("seasonal February = %+v, want 20000", seasonal.Items[0])
+	}
+	if seasonal.Items[11].ProjectedExpense != 10000 {
+		t.Errorf("seasonal January expense = %s, want 10000", seasonal.Items[11].ProjectedExpense)
+	}
+}
+
+func TestSeasonalAdjustments(t *testing.T) {
+	history := []monthTotals{
+		{month: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), donation: 9000, expense: 1000},
+		{month: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), donation: 3000, expense: 1000},
+		{month: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), donation: 7000, expense: 4000},
+		{month: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), donation: 1000, expense: 2000},
+	}
+	donation, expense := seasonalAdjustments(history)
+	// The overall means are 5000 and 2000.
+	if donation[time.January] != 3000 || donation[time.February] != -3000 {
+		t.Errorf("donation adjustments = %v", donation)
+	}
+	if expense[time.January] != 500 || expense[time.February] != -500 {
+		t.Errorf("expense adjustments = %v", expense)
+	}
+	if _, ok := donation[time.March]; ok {
+		t.Errorf("months without history should not be adjusted: %v", donation)
+	}
+
+	donation, expense = seasonalAdjustments(nil)
+	if len(donation) != 0 || len(expense) != 0 {
+		t.Errorf("empty history gave %v, %v", donation, expense)
+	}
+}
+
+func TestAverageMoney(t *testing.T) {
+	cases := []struct {
+		total model.Money
+		n     int
+		want  model.Money
+	}{
+		{0, 3, 0},
+		{5, 0, 0},
+		{10, 3, 3},
+		{11, 2, 6},
+		{-11, 2, -6},
+		{-10, 3, -3},
+		{20, 3, 7},
+	}
+	for _, tc := range cases {
+		if got := averageMoney(tc.total, tc.n); got != tc.want {
+			t.Errorf("averageMoney(%d, %d) = %d, want %d", tc.total, tc.n, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+// maxEntryHistory caps how many change records one entry history returns.
+const maxEntryHistory = 200
+
+var (
+	ErrSameApprover              = errors.New("a different admin must approve this change")
+	ErrInvalidLedgerChangeStatus = errors.New("invalid status, expected pending, approved or rejected")
+	errUnknownLedgerChangeAction = errors.New("unsupported ledger change action")
+)
+
+// LedgerApprovalService routes ledger writes above the configured threshold
+// through a second admin. Writes at or below it, or every write when the
+// threshold is zero, go straight to the ledger as before.
+type LedgerApprovalService struct {
+	changes   repository.LedgerChangeRepository
+	entries   repository.LedgerRepository
+	ledger    *LedgerService
+	threshold model.Money
+}
+
+// LedgerWriteResult reports either the entry that was written or, when the
+// write needs a second admin, the pending change it was queued as.
+type LedgerWriteResult struct {
+	EntryID  uint64
+	ChangeID uint64
+	Pending  bool
+	// Reused reports a retried create that matched an earlier entry or
+	// queued change.
+	Reused bool
+}
+
+type ListLedgerChangesInput struct {
+	Status   string
+	Page     int
+	PageSize int
+}
+
+type ListLedgerChangesResult struct {
+	Items      []model.LedgerChange
+	Page       int
+	PageSize   int
+	Total      int64
+	TotalPages int
+}
+
+func NewLedgerApprovalService(changes repository.LedgerChangeRepository, entries repository.LedgerRepository, ledger *LedgerService, threshold model.Money) *LedgerApprovalService {
+	return &LedgerApprovalService{changes: changes, entries: entries, ledger: ledger, threshold: threshold}
+}
+
+func (s *LedgerApprovalService) requiresApproval(amount model.Money) bool {
+	return s.threshold > 0 && amount > s.threshold
+}
+
+func (s *LedgerApprovalService) SubmitExpense(ctx context.Context, input ExpenseInput) (LedgerWriteResult, error) {
+	entry, err := s.ledger.prepareExpense(input)
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	if !s.requiresApproval(entry.Amount) {
+		entryID, reused, err := s.ledger.CreateExpense(ctx, input)
+		return LedgerWriteResult{EntryID: entryID, Reused: reused}, err
+	}
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	changeID, reused, err := s.changes.CreateChange(ctx, repository.CreateLedgerChangeInput{
+		Action:      model.LedgerChangeCreate,
+		EntryType:   model.LedgerEntryTypeExpense,
+		Amount:      entry.Amount,
+		Payload:     payload,
+		RequestedBy: input.ActorUserID,
+		RequestID:   strings.TrimSpace(input.RequestID),
+	})
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	if reused {
+		result, err := s.resultOf(ctx, changeID)
+		result.Reused = true
+		return result, err
+	}
+	return LedgerWriteResult{ChangeID: changeID, Pending: true}, nil
+}
+
+// SubmitUpdate holds an edit for approval when either the stored or the new
+// amount is above the threshold, so large entries cannot be shrunk quietly.
+func (s *LedgerApprovalService) SubmitUpdate(ctx context.Context, actorUserID uint64, input UpdateLedgerEntryInput) (LedgerWriteResult, error) {
+	update, entry, err := s.ledger.prepareUpdate(ctx, input)
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	if !s.requiresApproval(entry.Amount) && !s.requiresApproval(update.Amount) {
+		if err := s.entries.UpdateEntry(ctx, update); err != nil {
+			return LedgerWriteResult{}, err
+		}
+		return LedgerWriteResult{EntryID: entry.ID}, nil
+	}
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	changeID, _, err := s.changes.CreateChange(ctx, repository.CreateLedgerChangeInput{
+		Action:      model.LedgerChangeUpdate,
+		EntryID:     &entry.ID,
+		EntryType:   entry.EntryType,
+		Amount:      update.Amount,
+		Payload:     payload,
+		RequestedBy: actorUserID,
+	})
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	return LedgerWriteResult{EntryID: entry.ID, ChangeID: changeID, Pending: true}, nil
+}
+
+func (s *LedgerApprovalService) SubmitDelete(ctx context.Context, actorUserID, entryID uint64) (LedgerWriteResult, error) {
+	entry, err := s.entries.GetEntryByID(ctx, entryID)
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	if !s.requiresApproval(entry.Amount) {
+		if err := s.entries.SoftDeleteEntry(ctx, entryID, actorUserID); err != nil {
+			return LedgerWriteResult{}, err
+		}
+		return LedgerWriteResult{EntryID: entryID}, nil
+	}
+
+	changeID, _, err := s.changes.CreateChange(ctx, repository.CreateLedgerChangeInput{
+		Action:      model.LedgerChangeDelete,
+		EntryID:     &entry.ID,
+		EntryType:   entry.EntryType,
+		Amount:      entry.Amount,
+		Payload:     json.RawMessage("{}"),
+		RequestedBy: actorUserID,
+	})
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	return LedgerWriteResult{EntryID: entryID, ChangeID: changeID, Pending: true}, nil
+}
+
+func (s *LedgerApprovalService) Get(ctx context.Context, id uint64) (model.LedgerChange, error) {
+	return s.changes.GetChangeByID(ctx, id)
+}
+
+func (s *LedgerApprovalService) List(ctx context.Context, input ListLedgerChangesInput) (ListLedgerChangesResult, error) {
+	page, pageSize, err := normalizePagination(input.Page, input.PageSize)
+	if err != nil {
+		return ListLedgerChangesResult{}, err
+	}
+	status := model.LedgerChangeStatus(strings.ToLower(strings.TrimSpace(input.Status)))
+	switch status {
+	case "", model.LedgerChangeStatusPending, model.LedgerChangeStatusApproved, model.LedgerChangeStatusRejected:
+	default:
+		return ListLedgerChangesResult{}, ErrInvalidLedgerChangeStatus
+	}
+
+	filter := repository.ListLedgerChangesFilter{
+		Status: status,
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	}
+	items, err := s.changes.ListChanges(ctx, filter)
+	if err != nil {
+		return ListLedgerChangesResult{}, err
+	}
+	total, err := s.changes.CountChanges(ctx, filter)
+	if err != nil {
+		return ListLedgerChangesResult{}, err
+	}
+
+	return ListLedgerChangesResult{
+		Items:      items,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// EntryHistory lists the approval-gated changes made to an entry, oldest first.
+func (s *LedgerApprovalService) EntryHistory(ctx context.Context, entryID uint64) ([]model.LedgerChange, error) {
+	if _, err := s.entries.GetEntryByID(ctx, entryID); err != nil {
+		return nil, err
+	}
+	return s.changes.ListChanges(ctx, repository.ListLedgerChangesFilter{EntryID: entryID, Limit: maxEntryHistory})
+}
+
+// Approve applies a pending change on behalf of the admin who requested it.
+// The change is claimed before it is applied, so of two admins approving at
+// once, or an approval racing a rejection, only one wins; a change that
+// cannot be applied is released back to pending. A queued create is booked
+// with a request id derived from the change, so approving it again after a
+// failure reuses any entry created the first time.
+func (s *LedgerApprovalService) Approve(ctx context.Context, id, reviewerID uint64) (uint64, error) {
+	change, err := s.changes.GetChangeByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if change.Status != model.LedgerChangeStatusPending {
+		return 0, repository.ErrLedgerChangeNotPending
+	}
+	if change.RequestedBy == reviewerID {
+		return 0, ErrSameApprover
+	}
+
+	if err := s.changes.ClaimChange(ctx, change.ID, reviewerID); err != nil {
+		return 0, err
+	}
+	entryID, err := s.apply(ctx, change)
+	if err != nil {
+		// apply may have failed because ctx was cancelled; the release must
+		// still run or the change stays claimed with nothing applied.
+		if releaseErr := s.changes.ReleaseChange(context.WithoutCancel(ctx), change.ID, reviewerID); releaseErr != nil {
+			return 0, errors.Join(err, releaseErr)
+		}
+		return 0, err
+	}
+	if change.Action == model.LedgerChangeCreate {
+		if err := s.changes.SetChangeEntry(ctx, change.ID, entryID); err != nil {
+			return 0, err
+		}
+	}
+	return entryID, nil
+}
+
+// apply writes a claimed change to the ledger and returns the entry it
+// touched.
+func (s *LedgerApprovalService) apply(ctx context.Context, change model.LedgerChange) (uint64, error) {
+	switch change.Action {
+	case model.LedgerChangeCreate:
+		var input ExpenseInput
+		if err := json.Unmarshal(change.Payload, &input); err != nil {
+			return 0, err
+		}
+		input.ActorUserID = change.RequestedBy
+		input.RequestID = fmt.Sprintf("ledger-change-%d", change.ID)
+		entryID, _, err := s.ledger.CreateExpense(ctx, input)
+		return entryID, err
+	case model.LedgerChangeUpdate:
+		var input UpdateLedgerEntryInput
+		if err := json.Unmarshal(change.Payload, &input); err != nil {
+			return 0, err
+		}
+		input.EntryID = *change.EntryID
+		return input.EntryID, s.ledger.UpdateLedgerEntry(ctx, input)
+	case model.LedgerChangeDelete:
+		return *change.EntryID, s.entries.SoftDeleteEntry(ctx, *change.EntryID, change.RequestedBy)
+	default:
+		return 0, errUnknownLedgerChangeAction
+	}
+}
+
+func (s *LedgerApprovalService) Reject(ctx context.Context, id, reviewerID uint64, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("reason is required")
+	}
+	return s.changes.MarkChangeRejected(ctx, id, reviewerID, reason)
+}
+
+func (s *LedgerApprovalService) resultOf(ctx context.Context, changeID uint64) (LedgerWriteResult, error) {
+	change, err := s.changes.GetChangeByID(ctx, changeID)
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	result := LedgerWriteResult{ChangeID: change.ID, Pending: change.Status == model.LedgerChangeStatusPending}
+	if change.EntryID != nil {
+		result.EntryID = *change.EntryID
+	}
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+// claimRepository serves one change and records whether its claim was
+// released with a live context.
+type claimRepository struct {
+	repository.LedgerChangeRepository
+	change     model.LedgerChange
+	releaseErr error
+	released   bool
+}
+
+func (r *claimRepository) GetChangeByID(context.Context, uint64) (model.LedgerChange, error) {
+	return r.change, nil
+}
+
+func (r *claimRepository) ClaimChange(context.Context, uint64, uint64) error {
+	return nil
+}
+
+func (r *claimRepository) ReleaseChange(ctx context.Context, _, _ uint64) error {
+	r.released = true
+	r.releaseErr = ctx.Err()
+	return r.releaseErr
+}
+
+// cancelledRepository fails deletions the way the driver does once the
+// request context is gone.
+type cancelledRepository struct {
+	repository.LedgerRepository
+}
+
+func (cancelledRepository) SoftDeleteEntry(ctx context.Context, _, _ uint64) error {
+	return ctx.Err()
+}
+
+func TestApproveReleasesClaimAfterCancel(t *testing.T) {
+	entryID := uint64(3)
+	changes := &claimRepository{change: model.LedgerChange{
+		ID:          1,
+		Action:      model.LedgerChangeDelete,
+		EntryID:     &entryID,
+		Status:      model.LedgerChangeStatusPending,
+		RequestedBy: 10,
+	}}
+	entries := cancelledRepository{}
+	svc := NewLedgerApprovalService(changes, entries, NewLedgerService(entries, time.UTC), 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := svc.Approve(ctx, 1, 20); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Approve error = %v, want context.Canceled", err)
+	}
+	if !changes.released || changes.releaseErr != nil {
+		t.Errorf("released = %v with %v, want a release with a live context", changes.released, changes.releaseErr)
+	}
+}
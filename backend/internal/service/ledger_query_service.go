@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"propets/backend/internal/model"
 	"propets/backend/internal/repository"
@@ -29,24 +30,29 @@ var (
 
 type LedgerQueryService struct {
 	repo repository.LedgerRepository
+	// loc decides which month "now" falls in.
+	loc *time.Location
 }
 
 type MonthlySummary struct {
-	DonationTotal string `json:"donation_total"`
-	ExpenseTotal  string `json:"expense_total"`
-	Balance       string `json:"balance"`
+	DonationTotal model.Money `json:"donation_total"`
+	ExpenseTotal  model.Money `json:"expense_total"`
+	Balance       model.Money `json:"balance"`
 }
 
 type MonthlyStatistic struct {
-	Month             string `json:"month"`
-	DonationTotal     string `json:"donation_total"`
-	ExpenseTotal      string `json:"expense_total"`
-	CumulativeBalance string `json:"cumulative_balance"`
+	Month             string      `json:"month"`
+	DonationTotal     model.Money `json:"donation_total"`
+	ExpenseTotal      model.Money `json:"expense_total"`
+	CumulativeBalance model.Money `json:"cumulative_balance"`
 }
 
 type ListEntriesInput struct {
-	Month    string
-	Type     string
+	Month string
+	Type  string
+	Tag   string
+	// Deleted lists soft-deleted entries instead of live ones.
+	Deleted  bool
 	Page     int
 	PageSize int
 }
@@ -59,8 +65,8 @@ type ListEntriesResult struct {
 	TotalPages int                 `json:"total_pages"`
 }
 
-func NewLedgerQueryService(repo repository.LedgerRepository) *LedgerQueryService {
-	return &LedgerQueryService{repo: repo}
+func NewLedgerQueryService(repo repository.LedgerRepository, loc *time.Location) *LedgerQueryService {
+	return &LedgerQueryService{repo: repo, loc: loc}
 }
 
 func (s *LedgerQueryService) GetMonthlySummary(ctx context.Context, month string) (MonthlySummary, error) {
@@ -82,7 +88,7 @@ func (s *LedgerQueryService) GetMonthlySummary(ctx context.Context, month string
 }
 
 func (s *LedgerQueryService) ListMonthlyStatistics(ctx context.Context) ([]MonthlyStatistic, error) {
-	stats, err := s.repo.ListMonthlyStatistics(ctx)
+	stats, err := s.repo.ListMonthlyStatistics(ctx, monthKeyOf(time.Now(), s.loc))
 	if err != nil {
 		return nil, err
 	}
@@ -100,6 +106,12 @@ func (s *LedgerQueryService) ListMonthlyStatistics(ctx context.Context) ([]Month
 	return items, nil
 }
 
+// monthKeyOf returns the YYYY-MM month t falls in within loc, the same key
+// the database derives from the stored wall time.
+func monthKeyOf(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01")
+}
+
 func (s *LedgerQueryService) ListEntries(ctx context.Context, input ListEntriesInput) (ListEntriesResult, error) {
 	normalized, err := normalizeListEntriesInput(input)
 	if err != nil {
@@ -109,6 +121,8 @@ func (s *LedgerQueryService) ListEntries(ctx context.Context, input ListEntriesI
 	filter := repository.ListLedgerEntriesFilter{
 		MonthKey: normalized.Month,
 		Type:     model.LedgerEntryType(normalized.Type),
+		Tag:      normalized.Tag,
+		Deleted:  normalized.Deleted,
 		Limit:    normalized.PageSize,
 		Offset:   (normalized.Page - 1) * normalized.PageSize,
 	}
@@ -141,6 +155,8 @@ func normalizeListEntriesInput(input ListEntriesInput) (ListEntriesInput, error)
 	normalized := ListEntriesInput{
 		Month:    strings.TrimSpace(input.Month),
 		Type:     strings.TrimSpace(strings.ToLower(input.Type)),
+		Tag:      strings.TrimSpace(input.Tag),
+		Deleted:  input.Deleted,
 		Page:     input.Page,
 		PageSize: input.PageSize,
 	}
@@ -192,3 +208,22 @@ func validateMonth(month string) error {
 func (s *LedgerQueryService) SoftDeleteEntry(ctx context.Context, entryID uint64, deletedBy uint64) error {
 	return s.repo.SoftDeleteEntry(ctx, entryID, deletedBy)
 }
+
+type TagUsage struct {
+	Name          string      `json:"name"`
+	EntryCount    int64       `json:"entry_count"`
+	DonationTotal model.Money `json:"donation_total"`
+	ExpenseTotal  model.Money `json:"expense_total"`
+}
+
+func (s *LedgerQueryService) ListTags(ctx context.Context) ([]TagUsage, error) {
+	usage, err := s.repo.ListTagUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]TagUsage, 0, len(usage))
+	for _, item := range usage {
+		items = append(items, TagUsage(item))
+	}
+	return items, nil
+}
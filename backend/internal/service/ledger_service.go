@@ -16,6 +16,7 @@ type DonationInput struct {
 	Donor       string
 	DonatedAt   string
 	Amount      string
+	Tags        []string
 	RequestID   string
 }
 
@@ -25,9 +26,18 @@ type ExpenseInput struct {
 	Amount      string
 	HandledBy   string
 	OccurredAt  string
+	Category    string
+	Lines       []ExpenseLineInput
+	Tags        []string
 	RequestID   string
 }
 
+type ExpenseLineInput struct {
+	Description string
+	Amount      string
+	Category    string
+}
+
 type UpdateLedgerEntryInput struct {
 	EntryID    uint64
 	Donor      string
@@ -35,15 +45,29 @@ type UpdateLedgerEntryInput struct {
 	Purpose    string
 	HandledBy  string
 	OccurredAt string
+	Category   string
 	Amount     string
+	// Lines replaces the line items of an expense. A nil slice keeps the
+	// stored lines, which must then still add up to Amount.
+	Lines []ExpenseLineInput
+	// Tags replaces the tags of the entry; a nil slice keeps them.
+	Tags []string
 }
 
+const (
+	maxExpenseLines = 50
+	maxEntryTags    = 10
+	maxTagLen       = 32
+)
+
 type LedgerService struct {
 	repo repository.LedgerRepository
+	// loc is the organization timezone plain dates are entered in.
+	loc *time.Location
 }
 
-func NewLedgerService(repo repository.LedgerRepository) *LedgerService {
-	return &LedgerService{repo: repo}
+func NewLedgerService(repo repository.LedgerRepository, loc *time.Location) *LedgerService {
+	return &LedgerService{repo: repo, loc: loc}
 }
 
 func (s *LedgerService) CreateDonation(ctx context.Context, input DonationInput) (uint64, bool, error) {
@@ -54,21 +78,27 @@ func (s *LedgerService) CreateDonation(ctx context.Context, input DonationInput)
 	if strings.TrimSpace(input.RequestID) == "" {
 		return 0, false, errors.New("request id is required")
 	}
-	if err := model.ValidateAmount(input.Amount); err != nil {
+	amount, err := model.ParseAmount(input.Amount)
+	if err != nil {
 		return 0, false, err
 	}
 
-	donatedAt, err := parseOccurredAt(input.DonatedAt)
+	donatedAt, err := parseOccurredAt(input.DonatedAt, s.loc)
 	if err != nil {
 		return 0, false, fmt.Errorf("invalid donatedAt: %w", err)
 	}
+	tags, err := parseTags(input.Tags)
+	if err != nil {
+		return 0, false, err
+	}
 
 	entryID, reused, err := s.repo.CreateEntryWithRequestID(ctx, repository.CreateLedgerEntryInput{
 		UserID:      input.ActorUserID,
 		EntryType:   model.LedgerEntryTypeDonation,
-		Amount:      strings.TrimSpace(input.Amount),
+		Amount:      amount,
 		OccurredAt:  donatedAt,
-		Description: fmt.Sprintf("donor=%s", donor),
+		Description: donationDescription(donor),
+		Tags:        tags,
 	}, strings.TrimSpace(input.RequestID))
 	if err != nil {
 		return 0, false, err
@@ -77,97 +107,169 @@ func (s *LedgerService) CreateDonation(ctx context.Context, input DonationInput)
 }
 
 func (s *LedgerService) CreateExpense(ctx context.Context, input ExpenseInput) (uint64, bool, error) {
+	entry, err := s.prepareExpense(input)
+	if err != nil {
+		return 0, false, err
+	}
+
+	entryID, reused, err := s.repo.CreateEntryWithRequestID(ctx, entry, strings.TrimSpace(input.RequestID))
+	if err != nil {
+		return 0, false, err
+	}
+	return entryID, reused, nil
+}
+
+// prepareExpense validates an expense request and builds the row to insert.
+func (s *LedgerService) prepareExpense(input ExpenseInput) (repository.CreateLedgerEntryInput, error) {
 	purpose := strings.TrimSpace(input.Purpose)
 	handledBy := strings.TrimSpace(input.HandledBy)
 	if purpose == "" {
-		return 0, false, errors.New("purpose is required")
+		return repository.CreateLedgerEntryInput{}, errors.New("purpose is required")
 	}
 	if handledBy == "" {
-		return 0, false, errors.New("handledBy is required")
+		return repository.CreateLedgerEntryInput{}, errors.New("handledBy is required")
 	}
 	if strings.TrimSpace(input.RequestID) == "" {
-		return 0, false, errors.New("request id is required")
+		return repository.CreateLedgerEntryInput{}, errors.New("request id is required")
 	}
-	if err := model.ValidateAmount(input.Amount); err != nil {
-		return 0, false, err
+	amount, err := model.ParseAmount(input.Amount)
+	if err != nil {
+		return repository.CreateLedgerEntryInput{}, err
 	}
 
-	occurredAt, err := parseOccurredAt(input.OccurredAt)
+	category, err := parseExpenseCategory(input.Category, model.ExpenseCategoryOther)
 	if err != nil {
-		return 0, false, fmt.Errorf("invalid occurredAt: %w", err)
+		return repository.CreateLedgerEntryInput{}, err
+	}
+	lines, err := parseExpenseLines(input.Lines, amount)
+	if err != nil {
+		return repository.CreateLedgerEntryInput{}, err
 	}
 
-	entryID, reused, err := s.repo.CreateEntryWithRequestID(ctx, repository.CreateLedgerEntryInput{
+	occurredAt, err := parseOccurredAt(input.OccurredAt, s.loc)
+	if err != nil {
+		return repository.CreateLedgerEntryInput{}, fmt.Errorf("invalid occurredAt: %w", err)
+	}
+	tags, err := parseTags(input.Tags)
+	if err != nil {
+		return repository.CreateLedgerEntryInput{}, err
+	}
+
+	return repository.CreateLedgerEntryInput{
 		UserID:      input.ActorUserID,
 		EntryType:   model.LedgerEntryTypeExpense,
-		Amount:      strings.TrimSpace(input.Amount),
+		Amount:      amount,
 		OccurredAt:  occurredAt,
-		Description: fmt.Sprintf("purpose=%s;handled_by=%s", purpose, handledBy),
-	}, strings.TrimSpace(input.RequestID))
+		Description: expenseDescription(purpose, handledBy),
+		Category:    category,
+		Lines:       lines,
+		Tags:        tags,
+	}, nil
+}
+
+func (s *LedgerService) UpdateLedgerEntry(ctx context.Context, input UpdateLedgerEntryInput) error {
+	update, _, err := s.prepareUpdate(ctx, input)
 	if err != nil {
-		return 0, false, err
+		return err
 	}
-	return entryID, reused, nil
+	return s.repo.UpdateEntry(ctx, update)
 }
 
-func (s *LedgerService) UpdateLedgerEntry(ctx context.Context, input UpdateLedgerEntryInput) error {
+// prepareUpdate validates an edit against the stored entry and returns the
+// new row together with the entry as it is now.
+func (s *LedgerService) prepareUpdate(ctx context.Context, input UpdateLedgerEntryInput) (repository.UpdateLedgerEntryInput, model.LedgerEntry, error) {
 	if input.EntryID == 0 {
-		return errors.New("entry id is required")
+		return repository.UpdateLedgerEntryInput{}, model.LedgerEntry{}, errors.New("entry id is required")
 	}
-	if err := model.ValidateAmount(input.Amount); err != nil {
-		return err
+	amount, err := model.ParseAmount(input.Amount)
+	if err != nil {
+		return repository.UpdateLedgerEntryInput{}, model.LedgerEntry{}, err
 	}
 
 	entry, err := s.repo.GetEntryByID(ctx, input.EntryID)
 	if err != nil {
-		return err
+		return repository.UpdateLedgerEntryInput{}, model.LedgerEntry{}, err
+	}
+	tags := entry.Tags
+	if input.Tags != nil {
+		if tags, err = parseTags(input.Tags); err != nil {
+			return repository.UpdateLedgerEntryInput{}, entry, err
+		}
 	}
-
-	amount := strings.TrimSpace(input.Amount)
 
 	switch entry.EntryType {
 	case model.LedgerEntryTypeDonation:
+		if len(input.Lines) > 0 {
+			return repository.UpdateLedgerEntryInput{}, entry, errors.New("line items are only supported for expenses")
+		}
 		donor := strings.TrimSpace(input.Donor)
 		if donor == "" {
-			return errors.New("donor is required")
+			return repository.UpdateLedgerEntryInput{}, entry, errors.New("donor is required")
 		}
-		donatedAt, err := parseOccurredAt(input.DonatedAt)
+		donatedAt, err := parseOccurredAt(input.DonatedAt, s.loc)
 		if err != nil {
-			return fmt.Errorf("invalid donatedAt: %w", err)
+			return repository.UpdateLedgerEntryInput{}, entry, fmt.Errorf("invalid donatedAt: %w", err)
 		}
 
-		return s.repo.UpdateEntry(ctx, repository.UpdateLedgerEntryInput{
+		return repository.UpdateLedgerEntryInput{
 			EntryID:     input.EntryID,
 			Amount:      amount,
 			OccurredAt:  donatedAt,
-			Description: fmt.Sprintf("donor=%s", donor),
-		})
+			Description: donationDescription(donor),
+			Tags:        tags,
+		}, entry, nil
 	case model.LedgerEntryTypeExpense:
 		purpose := strings.TrimSpace(input.Purpose)
 		handledBy := strings.TrimSpace(input.HandledBy)
 		if purpose == "" {
-			return errors.New("purpose is required")
+			return repository.UpdateLedgerEntryInput{}, entry, errors.New("purpose is required")
 		}
 		if handledBy == "" {
-			return errors.New("handledBy is required")
+			return repository.UpdateLedgerEntryInput{}, entry, errors.New("handledBy is required")
+		}
+		category, err := parseExpenseCategory(input.Category, entry.Category)
+		if err != nil {
+			return repository.UpdateLedgerEntryInput{}, entry, err
+		}
+		var lines []repository.LedgerEntryLineInput
+		if input.Lines != nil {
+			lines, err = parseExpenseLines(input.Lines, amount)
+		} else {
+			lines, err = keepExpenseLines(entry.Lines, amount)
 		}
-		occurredAt, err := parseOccurredAt(input.OccurredAt)
 		if err != nil {
-			return fmt.Errorf("invalid occurredAt: %w", err)
+			return repository.UpdateLedgerEntryInput{}, entry, err
+		}
+		occurredAt, err := parseOccurredAt(input.OccurredAt, s.loc)
+		if err != nil {
+			return repository.UpdateLedgerEntryInput{}, entry, fmt.Errorf("invalid occurredAt: %w", err)
 		}
 
-		return s.repo.UpdateEntry(ctx, repository.UpdateLedgerEntryInput{
+		return repository.UpdateLedgerEntryInput{
 			EntryID:     input.EntryID,
 			Amount:      amount,
 			OccurredAt:  occurredAt,
-			Description: fmt.Sprintf("purpose=%s;handled_by=%s", purpose, handledBy),
-		})
+			Description: expenseDescription(purpose, handledBy),
+			Category:    category,
+			Lines:       lines,
+			Tags:        tags,
+		}, entry, nil
 	default:
-		return errors.New("invalid entry type")
+		return repository.UpdateLedgerEntryInput{}, entry, errors.New("invalid entry type")
 	}
 }
 
-func parseOccurredAt(raw string) (time.Time, error) {
+func donationDescription(donor string) string {
+	return fmt.Sprintf("donor=%s", donor)
+}
+
+func expenseDescription(purpose, handledBy string) string {
+	return fmt.Sprintf("purpose=%s;handled_by=%s", purpose, handledBy)
+}
+
+// parseOccurredAt accepts an RFC3339 timestamp or a plain date, which is
+// taken as midnight in loc.
+func parseOccurredAt(raw string, loc *time.Location) (time.Time, error) {
 	value := strings.TrimSpace(raw)
 	if value == "" {
 		return time.Time{}, errors.New("time value is required")
@@ -176,9 +278,108 @@ func parseOccurredAt(raw string) (time.Time, error) {
 	if t, err := time.Parse(time.RFC3339, value); err == nil {
 		return t, nil
 	}
-	if t, err := time.ParseInLocation("2006-01-02", value, time.FixedZone("CST", 8*3600)); err == nil {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
 		return t, nil
 	}
 
 	return time.Time{}, errors.New("must be RFC3339 or YYYY-MM-DD")
 }
+
+// parseTags trims and de-duplicates tag names, keeping their order. Tag names
+// compare case-insensitively in the database, so "Cat" and "cat" are one tag
+// and the first spelling given is kept.
+func parseTags(raw []string) ([]string, error) {
+	if len(raw) > maxEntryTags {
+		return nil, fmt.Errorf("invalid tags: at most %d tags", maxEntryTags)
+	}
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, tag := range raw {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, errors.New("invalid tags: tag must not be empty")
+		}
+		if len([]rune(tag)) > maxTagLen {
+			return nil, fmt.Errorf("invalid tags: %q is longer than %d characters", tag, maxTagLen)
+		}
+		key := strings.ToLower(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// parseExpenseCategory falls back to the given category when raw is empty,
+// so callers can default new expenses and keep the stored value on update.
+func parseExpenseCategory(raw string, fallback model.ExpenseCategory) (model.ExpenseCategory, error) {
+	if strings.TrimSpace(raw) == "" {
+		if fallback == "" {
+			return model.ExpenseCategoryOther, nil
+		}
+		return fallback, nil
+	}
+	return model.ParseExpenseCategory(raw)
+}
+
+func parseExpenseLines(inputs []ExpenseLineInput, total model.Money) ([]repository.LedgerEntryLineInput, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	if len(inputs) > maxExpenseLines {
+		return nil, fmt.Errorf("invalid lines: at most %d line items are allowed", maxExpenseLines)
+	}
+
+	lines := make([]repository.LedgerEntryLineInput, 0, len(inputs))
+	var sum model.Money
+	for i, input := range inputs {
+		description := strings.TrimSpace(input.Description)
+		if description == "" {
+			return nil, fmt.Errorf("line %d: description is required", i+1)
+		}
+		amount, err := model.ParseAmount(input.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		var category model.ExpenseCategory
+		if strings.TrimSpace(input.Category) != "" {
+			category, err = model.ParseExpenseCategory(input.Category)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+		sum += amount
+		lines = append(lines, repository.LedgerEntryLineInput{
+			Description: description,
+			Amount:      amount,
+			Category:    category,
+		})
+	}
+	if sum != total {
+		return nil, fmt.Errorf("line item amounts add up to %s, expected amount %s", sum, total)
+	}
+	return lines, nil
+}
+
+func keepExpenseLines(existing []model.LedgerEntryLine, total model.Money) ([]repository.LedgerEntryLineInput, error) {
+	if len(existing) == 0 {
+		return nil, nil
+	}
+
+	lines := make([]repository.LedgerEntryLineInput, 0, len(existing))
+	var sum model.Money
+	for _, line := range existing {
+		sum += line.Amount
+		lines = append(lines, repository.LedgerEntryLineInput{
+			Description: line.Description,
+			Amount:      line.Amount,
+			Category:    line.Category,
+		})
+	}
+	if sum != total {
+		return nil, fmt.Errorf("line item amounts add up to %s, expected amount %s; send updated lines", sum, total)
+	}
+	return lines, nil
+}
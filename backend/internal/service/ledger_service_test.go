@@ -0,0 +1,31 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	cases := []struct {
+		raw  []string
+		want []string
+	}{
+		{nil, []string{}},
+		{[]string{" 救助 ", "救助"}, []string{"救助"}},
+		{[]string{"Cat", "cat", "CAT"}, []string{"Cat"}},
+		{[]string{"dog", "Cat", "DOG", "cat"}, []string{"dog", "Cat"}},
+	}
+	for _, tc := range cases {
+		got, err := parseTags(tc.raw)
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseTags(%q) = %q, %v; want %q", tc.raw, got, err, tc.want)
+		}
+	}
+
+	for _, raw := range [][]string{{""}, {"  "}, {strings.Repeat("猫", maxTagLen+1)}, make([]string, maxEntryTags+1)} {
+		if _, err := parseTags(raw); err == nil {
+			t.Errorf("parseTags(%q) should fail", raw)
+		}
+	}
+}
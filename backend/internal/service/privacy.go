@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"propets/backend/internal/model"
+)
+
+// DonorPrivacy decides how donor names appear on the public pages.
+type DonorPrivacy string
+
+const (
+	// DonorPrivacyFull shows donor names unchanged.
+	DonorPrivacyFull DonorPrivacy = "full"
+	// DonorPrivacyMask keeps the first character of each name, e.g. "张**".
+	DonorPrivacyMask DonorPrivacy = "mask"
+	// DonorPrivacyHide replaces every donor name with a placeholder.
+	DonorPrivacyHide DonorPrivacy = "hide"
+)
+
+const hiddenDonorName = "***"
+
+func ParseDonorPrivacy(raw string) (DonorPrivacy, error) {
+	switch policy := DonorPrivacy(strings.ToLower(strings.TrimSpace(raw))); policy {
+	case DonorPrivacyFull, DonorPrivacyMask, DonorPrivacyHide:
+		return policy, nil
+	case "":
+		return DonorPrivacyMask, nil
+	default:
+		return "", errors.New("invalid donor privacy policy, expected full, mask or hide")
+	}
+}
+
+// PublicDescription returns the description of entry as it may be shown
+// without login. Donations recorded through the app read "donor=<name>" and
+// get the name masked; any other donation text, such as the imported
+// history, cannot be masked reliably and is left out unless the policy is
+// DonorPrivacyFull. Expense descriptions are shown as recorded.
+func (p DonorPrivacy) PublicDescription(entry model.LedgerEntry) string {
+	if entry.EntryType != model.LedgerEntryTypeDonation || p == DonorPrivacyFull {
+		return entry.Description
+	}
+	donor, ok := strings.CutPrefix(entry.Description, "donor=")
+	if !ok {
+		return ""
+	}
+	return donationDescription(p.maskDonor(donor))
+}
+
+// maskDonor masks a donor name. Names confirmed from donation reports carry
+// the chat group as "group，name"; the group is kept, only the name masked.
+func (p DonorPrivacy) maskDonor(donor string) string {
+	group, name, found := strings.Cut(donor, "，")
+	if !found {
+		group, name = "", donor
+	}
+
+	switch p {
+	case DonorPrivacyHide:
+		name = hiddenDonorName
+	default:
+		name = maskName(name)
+	}
+	if found {
+		return group + "，" + name
+	}
+	return name
+}
+
+func maskName(name string) string {
+	runes := []rune(strings.TrimSpace(name))
+	if len(runes) <= 1 {
+		return "*"
+	}
+	return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+}
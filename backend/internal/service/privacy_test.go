@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+
+	"propets/backend/internal/model"
+)
+
+func TestDonorPrivacyPublicDescription(t *testing.T) {
+	donation := func(description string) model.LedgerEntry {
+		return model.LedgerEntry{EntryType: model.LedgerEntryTypeDonation, Description: description}
+	}
+
+	cases := []struct {
+		policy DonorPrivacy
+		entry  model.LedgerEntry
+		want   string
+	}{
+		{DonorPrivacyFull, donation("donor=张三丰"), "donor=张三丰"},
+		{DonorPrivacyMask, donation("donor=张三丰"), "donor=张**"},
+		{DonorPrivacyMask, donation("donor=A"), "donor=*"},
+		{DonorPrivacyMask, donation("donor=三群，顾栀"), "donor=三群，顾*"},
+		{DonorPrivacyHide, donation("donor=三群，顾栀"), "donor=三群，***"},
+		{DonorPrivacyHide, donation("donor=顾栀"), "donor=***"},
+		{DonorPrivacyMask, donation("[历史明细迁移] 2024-01.md L7 三群，顾栀，50，1月1日"), ""},
+		{DonorPrivacyFull, donation("[历史明细迁移] 2024-01.md L7 三群，顾栀，50，1月1日"), "[历史明细迁移] 2024-01.md L7 三群，顾栀，50，1月1日"},
+		{DonorPrivacyHide, model.LedgerEntry{EntryType: model.LedgerEntryTypeExpense, Description: "purpose=猫粮;handled_by=李四"}, "purpose=猫粮;handled_by=李四"},
+	}
+	for _, tc := range cases {
+		if got := tc.policy.PublicDescription(tc.entry); got != tc.want {
+			t.Fatalf("%s.PublicDescription(%q) = %q, want %q", tc.policy, tc.entry.Description, got, tc.want)
+		}
+	}
+}
+
+func TestParseDonorPrivacy(t *testing.T) {
+	if got, err := ParseDonorPrivacy(""); err != nil || got != DonorPrivacyMask {
+		t.Fatalf("ParseDonorPrivacy(\"\") = %q, %v; want mask", got, err)
+	}
+	if got, err := ParseDonorPrivacy(" Hide "); err != nil || got != DonorPrivacyHide {
+		t.Fatalf("ParseDonorPrivacy(\" Hide \") = %q, %v; want hide", got, err)
+	}
+	if _, err := ParseDonorPrivacy("partial"); err == nil {
+		t.Fatal("ParseDonorPrivacy(\"partial\") expected error")
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+const (
+	maxRecurringDayOfMonth = 28
+	// maxCatchUpPeriods bounds how many missed periods one template books per
+	// run; anything older is picked up on the following runs.
+	maxCatchUpPeriods = 24
+)
+
+// RecurringService books ledger entries from recurring templates. Every
+// occurrence goes through the ledger idempotency keys with a request id
+// derived from the template and period, so repeated runs never duplicate.
+// Expenses above the dual-approval threshold are queued for a second admin
+// instead of booked.
+type RecurringService struct {
+	repo      repository.RecurringRepository
+	ledger    *LedgerService
+	approvals *LedgerApprovalService
+}
+
+type CreateRecurringTemplateInput struct {
+	ActorUserID uint64
+	EntryType   string
+	Amount      string
+	Donor       string
+	Purpose     string
+	HandledBy   string
+	Category    string
+	Frequency   string
+	DayOfMonth  int
+	StartDate   string
+	EndDate     string
+}
+
+type UpdateRecurringTemplateInput struct {
+	ID     uint64
+	Active *bool
+	// EndDate replaces the end date when set; an empty string clears it.
+	EndDate *string
+}
+
+func NewRecurringService(repo repository.RecurringRepository, ledger *LedgerService, approvals *LedgerApprovalService) *RecurringService {
+	return &RecurringService{repo: repo, ledger: ledger, approvals: approvals}
+}
+
+func (s *RecurringService) Create(ctx context.Context, input CreateRecurringTemplateInput) (uint64, error) {
+	amount, err := model.ParseAmount(input.Amount)
+	if err != nil {
+		return 0, err
+	}
+
+	out := repository.CreateRecurringTemplateInput{
+		EntryType:  model.LedgerEntryType(strings.ToLower(strings.TrimSpace(input.EntryType))),
+		Amount:     amount,
+		DayOfMonth: input.DayOfMonth,
+		CreatedBy:  input.ActorUserID,
+	}
+	switch out.EntryType {
+	case model.LedgerEntryTypeDonation:
+		out.Donor = strings.TrimSpace(input.Donor)
+		if out.Donor == "" {
+			return 0, errors.New("donor is required")
+		}
+		if len([]rune(out.Donor)) > maxDonorNameLen {
+			return 0, fmt.Errorf("invalid donor: at most %d characters", maxDonorNameLen)
+		}
+	case model.LedgerEntryTypeExpense:
+		out.Purpose = strings.TrimSpace(input.Purpose)
+		out.HandledBy = strings.TrimSpace(input.HandledBy)
+		if out.Purpose == "" {
+			return 0, errors.New("purpose is required")
+		}
+		if out.HandledBy == "" {
+			return 0, errors.New("handledBy is required")
+		}
+		if len([]rune(out.Purpose)) > maxReimbursementPurposeLen {
+			return 0, fmt.Errorf("invalid purpose: at most %d characters", maxReimbursementPurposeLen)
+		}
+		if len([]rune(out.HandledBy)) > maxDonorNameLen {
+			return 0, fmt.Errorf("invalid handledBy: at most %d characters", maxDonorNameLen)
+		}
+		out.Category, err = parseExpenseCategory(input.Category, model.ExpenseCategoryOther)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, errors.New("invalid entryType, expected donation or expense")
+	}
+
+	frequency := model.RecurrenceFrequency(strings.ToLower(strings.TrimSpace(input.Frequency)))
+	switch frequency {
+	case "":
+		frequency = model.RecurrenceMonthly
+	case model.RecurrenceMonthly:
+	default:
+		return 0, errors.New("invalid frequency, expected monthly")
+	}
+	out.Frequency = frequency
+
+	if input.DayOfMonth < 1 || input.DayOfMonth > maxRecurringDayOfMonth {
+		return 0, fmt.Errorf("invalid dayOfMonth: must be between 1 and %d", maxRecurringDayOfMonth)
+	}
+
+	out.StartDate, err = parseRecurringDate(input.StartDate, s.ledger.loc)
+	if err != nil {
+		return 0, fmt.Errorf("invalid startDate: %w", err)
+	}
+	if strings.TrimSpace(input.EndDate) != "" {
+		endDate, err := parseRecurringDate(input.EndDate, s.ledger.loc)
+		if err != nil {
+			return 0, fmt.Errorf("invalid endDate: %w", err)
+		}
+		if endDate.Before(out.StartDate) {
+			return 0, errors.New("invalid endDate: must not be before startDate")
+		}
+		out.EndDate = &endDate
+	}
+
+	return s.repo.CreateTemplate(ctx, out)
+}
+
+func (s *RecurringService) Get(ctx context.Context, id uint64) (model.RecurringTemplate, error) {
+	return s.repo.GetTemplateByID(ctx, id)
+}
+
+func (s *RecurringService) List(ctx context.Context) ([]model.RecurringTemplate, error) {
+	return s.repo.ListTemplates(ctx, false)
+}
+
+func (s *RecurringService) Update(ctx context.Context, input UpdateRecurringTemplateInput) error {
+	current, err := s.repo.GetTemplateByID(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+
+	update := repository.UpdateRecurringTemplateInput{
+		ID:      current.ID,
+		Active:  current.Active,
+		EndDate: current.EndDate,
+	}
+	if input.Active != nil {
+		update.Active = *input.Active
+	}
+	if input.EndDate != nil {
+		update.EndDate = nil
+		if strings.TrimSpace(*input.EndDate) != "" {
+			endDate, err := parseRecurringDate(*input.EndDate, s.ledger.loc)
+			if err != nil {
+				return fmt.Errorf("invalid endDate: %w", err)
+			}
+			if endDate.Before(dateOf(current.StartDate, s.ledger.loc)) {
+				return errors.New("invalid endDate: must not be before startDate")
+			}
+			update.EndDate = &endDate
+		}
+	}
+
+	return s.repo.UpdateTemplate(ctx, update)
+}
+
+// Delete removes the template only; entries it already booked stay in the
+// ledger and can be corrected like any other entry.
+func (s *RecurringService) Delete(ctx context.Context, id uint64) error {
+	return s.repo.DeleteTemplate(ctx, id)
+}
+
+// MaterializeDue books every occurrence that is due at now and has not been
+// booked yet. It keeps going past a failing template and returns the number
+// of occurrences booked or queued for approval along with the joined errors.
+func (s *RecurringService) MaterializeDue(ctx context.Context, now time.Time) (int, error) {
+	templates, err := s.repo.ListTemplates(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	var errs []error
+	for _, template := range templates {
+		n, err := s.materializeTemplate(ctx, template, now)
+		created += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring template %d: %w", template.ID, err))
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+func (s *RecurringService) materializeTemplate(ctx context.Context, template model.RecurringTemplate, now time.Time) (int, error) {
+	loc := s.ledger.loc
+	start := dateOf(template.StartDate, loc)
+	period := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, loc)
+	if template.LastPeriod != "" {
+		last, err := time.ParseInLocation("2006-01", template.LastPeriod, loc)
+		if err != nil {
+			return 0, fmt.Errorf("invalid last period %q: %w", template.LastPeriod, err)
+		}
+		period = last.AddDate(0, 1, 0)
+	}
+
+	created := 0
+	for i := 0; i < maxCatchUpPeriods; i++ {
+		occurredAt := time.Date(period.Year(), period.Month(), template.DayOfMonth, 0, 0, 0, 0, loc)
+		if occurredAt.After(now) {
+			break
+		}
+		if template.EndDate != nil && occurredAt.After(dateOf(*template.EndDate, loc)) {
+			break
+		}
+
+		key := period.Format("2006-01")
+		if !occurredAt.Before(start) {
+			reused, err := s.bookOccurrence(ctx, template, key, occurredAt)
+			if err != nil {
+				return created, err
+			}
+			if !reused {
+				created++
+			}
+		}
+		if err := s.repo.SetTemplateLastPeriod(ctx, template.ID, key); err != nil {
+			return created, err
+		}
+		period = period.AddDate(0, 1, 0)
+	}
+	return created, nil
+}
+
+func (s *RecurringService) bookOccurrence(ctx context.Context, template model.RecurringTemplate, period string, occurredAt time.Time) (bool, error) {
+	requestID := recurringRequestID(template.ID, period)
+	date := occurredAt.Format("2006-01-02")
+
+	switch template.EntryType {
+	case model.LedgerEntryTypeDonation:
+		_, reused, err := s.ledger.CreateDonation(ctx, DonationInput{
+			ActorUserID: template.CreatedBy,
+			Donor:       template.Donor,
+			DonatedAt:   date,
+			Amount:      template.Amount.String(),
+			RequestID:   requestID,
+		})
+		return reused, err
+	case model.LedgerEntryTypeExpense:
+		result, err := s.approvals.SubmitExpense(ctx, ExpenseInput{
+			ActorUserID: template.CreatedBy,
+			Purpose:     template.Purpose,
+			Amount:      template.Amount.String(),
+			HandledBy:   template.HandledBy,
+			OccurredAt:  date,
+			Category:    string(template.Category),
+			RequestID:   requestID,
+		})
+		return result.Reused, err
+	default:
+		return false, errors.New("invalid entry type")
+	}
+}
+
+func recurringRequestID(templateID uint64, period string) string {
+	return fmt.Sprintf("recurring-%d-%s", templateID, period)
+}
+
+func parseRecurringDate(raw string, loc *time.Location) (time.Time, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return time.Time{}, errors.New("date value is required")
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, errors.New("must be YYYY-MM-DD")
+	}
+	return t, nil
+}
+
+// dateOf truncates t to midnight of its calendar day in loc.
+func dateOf(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
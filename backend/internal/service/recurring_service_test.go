@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+// bookingRepository keeps the entries booked through request ids; other
+// methods are not used by the recurring service.
+type bookingRepository struct {
+	repository.LedgerRepository
+	byRequestID map[string]uint64
+	booked      []string
+}
+
+func (r *bookingRepository) CreateEntryWithRequestID(_ context.Context, input repository.CreateLedgerEntryInput, requestID string) (uint64, bool, error) {
+	if id, ok := r.byRequestID[requestID]; ok {
+		return id, true, nil
+	}
+	id := uint64(len(r.byRequestID) + 1)
+	r.byRequestID[requestID] = id
+	r.booked = append(r.booked, requestID+" "+input.OccurredAt.Format("2006-01-02"))
+	return id, false, nil
+}
+
+// templateRepository records the last period set for each template.
+type templateRepository struct {
+	repository.RecurringRepository
+	lastPeriod map[uint64]string
+}
+
+func (r *templateRepository) SetTemplateLastPeriod(_ context.Context, id uint64, period string) error {
+	r.lastPeriod[id] = period
+	return nil
+}
+
+func recurringService() (*RecurringService, *bookingRepository, *templateRepository) {
+	entries := &bookingRepository{byRequestID: make(map[string]uint64)}
+	templates := &templateRepository{lastPeriod: make(map[uint64]string)}
+	ledger := NewLedgerService(entries, time.UTC)
+	approvals := NewLedgerApprovalService(nil, entries, ledger, 0)
+	return NewRecurringService(templates, ledger, approvals), entries, templates
+}
+
+func recurringDay(value string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", value, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestMaterializeTemplate(t *testing.T) {
+	end := recurringDay("2024-02-28")
+	cases := []struct {
+		name       string
+		template   model.RecurringTemplate
+		now        string
+		wantBooked []string
+		wantLast   string
+	}{
+		{
+			name:       "skips the month before the start date",
+			template:   model.RecurringTemplate{DayOfMonth: 10, StartDate: recurringDay("2024-01-15")},
+			now:        "2024-03-20",
+			wantBooked: []string{"recurring-7-2024-02 2024-02-10", "recurring-7-2024-03 2024-03-10"},
+			wantLast:   "2024-03",
+		},
+		{
+			name:       "stops at the end date",
+			template:   model.RecurringTemplate{DayOfMonth: 5, StartDate: recurringDay("2024-01-01"), EndDate: &end},
+			now:        "2024-06-01",
+			wantBooked: []string{"recurring-7-2024-01 2024-01-05", "recurring-7-2024-02 2024-02-05"},
+			wantLast:   "2024-02",
+		},
+		{
+			name:       "resumes after the last period",
+			template:   model.RecurringTemplate{DayOfMonth: 1, StartDate: recurringDay("2024-01-01"), LastPeriod: "2024-02"},
+			now:        "2024-04-02",
+			wantBooked: []string{"recurring-7-2024-03 2024-03-01", "recurring-7-2024-04 2024-04-01"},
+			wantLast:   "2024-04",
+		},
+		{
+			name:       "nothing due before the day of month",
+			template:   model.RecurringTemplate{DayOfMonth: 10, StartDate: recurringDay("2024-01-01"), LastPeriod: "2024-02"},
+			now:        "2024-03-09",
+			wantBooked: nil,
+			wantLast:   "",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, entries, templates := recurringService()
+			template := tc.template
+			template.ID = 7
+			template.EntryType = model.LedgerEntryTypeDonation
+			template.Donor = "monthly donor"
+			template.Amount = 5000
+
+			created, err := svc.materializeTemplate(context.Background(), template, recurringDay(tc.now))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if created != len(tc.wantBooked) {
+				t.Errorf("created = %d, want %d", created, len(tc.wantBooked))
+			}
+			if fmt.Sprint(entries.booked) != fmt.Sprint(tc.wantBooked) {
+				t.Errorf("booked = %v, want %v", entries.booked, tc.wantBooked)
+			}
+			if got := templates.lastPeriod[7]; got != tc.wantLast {
+				t.Errorf("last period = %q, want %q", got, tc.wantLast)
+			}
+		})
+	}
+}
+
+func TestMaterializeTemplateCatchUpIsBounded(t *testing.T) {
+	svc, entries, templates := recurringService()
+	template := model.RecurringTemplate{
+		ID:         7,
+		EntryType:  model.LedgerEntryTypeExpense,
+		Amount:     5000,
+		Purpose:    "rent",
+		HandledBy:  "treasurer",
+		DayOfMonth: 1,
+		StartDate:  recurringDay("2020-01-01"),
+	}
+
+	created, err := svc.materializeTemplate(context.Background(), template, recurringDay("2024-06-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != maxCatchUpPeriods || len(entries.booked) != maxCatchUpPeriods {
+		t.Errorf("created = %d, booked = %d, want %d", created, len(entries.booked), maxCatchUpPeriods)
+	}
+	if got := templates.lastPeriod[7]; got != "2021-12" {
+		t.Errorf("last period = %q, want 2021-12", got)
+	}
+
+	// The next run picks up where this one stopped.
+	template.LastPeriod = templates.lastPeriod[7]
+	if _, err := svc.materializeTemplate(context.Background(), template, recurringDay("2024-06-01")); err != nil {
+		t.Fatal(err)
+	}
+	if got := templates.lastPeriod[7]; got != "2023-12" {
+		t.Errorf("last period after the second run = %q, want 2023-12", got)
+	}
+}
+
+func TestMaterializeTemplateDoesNotDuplicate(t *testing.T) {
+	svc, entries, _ := recurringService()
+	template := model.RecurringTemplate{
+		ID:         7,
+		EntryType:  model.LedgerEntryTypeDonation,
+		Amount:     5000,
+		Donor:      "monthly donor",
+		DayOfMonth: 1,
+		StartDate:  recurringDay("2024-01-01"),
+	}
+	now := recurringDay("2024-03-15")
+
+	if created, err := svc.materializeTemplate(context.Background(), template, now); err != nil || created != 3 {
+		t.Fatalf("first run created %d, %v; want 3", created, err)
+	}
+	// A restart that lost the last period books the same request ids again,
+	// which the ledger resolves to the entries it already holds.
+	created, err := svc.materializeTemplate(context.Background(), template, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != 0 || len(entries.booked) != 3 {
+		t.Errorf("second run created %d, booked %d entries; want 0 and 3", created, len(entries.booked))
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+const (
+	maxReimbursementPurposeLen = 200
+	maxAttachmentURLLen        = 500
+)
+
+var (
+	ErrInvalidReimbursementStatus = errors.New("invalid status, expected pending, approved, unbooked or rejected")
+	// ErrReimbursementChangeRejected reports an approval whose queued expense
+	// was rejected by the second admin.
+	ErrReimbursementChangeRejected = errors.New("the expense for this reimbursement was rejected by the second admin")
+)
+
+type ReimbursementService struct {
+	repo   repository.ReimbursementRepository
+	ledger *LedgerService
+	// approvals books the expense, queueing it for a second admin above the
+	// dual-approval threshold; above it an admin may not approve their own
+	// request either.
+	approvals *LedgerApprovalService
+}
+
+type SubmitReimbursementInput struct {
+	RequesterID uint64
+	Amount      string
+	Purpose     string
+	Category    string
+	OccurredAt  string
+	ReceiptURL  string
+}
+
+type ListReimbursementsInput struct {
+	// RequesterID limits the listing to one member's requests; zero lists all.
+	RequesterID uint64
+	Status      string
+	Page        int
+	PageSize    int
+}
+
+type ListReimbursementsResult struct {
+	Items      []model.Reimbursement
+	Page       int
+	PageSize   int
+	Total      int64
+	TotalPages int
+}
+
+type ApproveReimbursementInput struct {
+	ReimbursementID uint64
+	ReviewerID      uint64
+	HandledBy       string
+}
+
+func NewReimbursementService(repo repository.ReimbursementRepository, ledger *LedgerService, approvals *LedgerApprovalService) *ReimbursementService {
+	return &ReimbursementService{repo: repo, ledger: ledger, approvals: approvals}
+}
+
+func (s *ReimbursementService) Submit(ctx context.Context, input SubmitReimbursementInput) (uint64, error) {
+	purpose := strings.TrimSpace(input.Purpose)
+	if purpose == "" {
+		return 0, errors.New("purpose is required")
+	}
+	if len([]rune(purpose)) > maxReimbursementPurposeLen {
+		return 0, fmt.Errorf("invalid purpose: at most %d characters", maxReimbursementPurposeLen)
+	}
+	amount, err := model.ParseAmount(input.Amount)
+	if err != nil {
+		return 0, err
+	}
+	category, err := parseExpenseCategory(input.Category, model.ExpenseCategoryOther)
+	if err != nil {
+		return 0, err
+	}
+	occurredAt, err := parseOccurredAt(input.OccurredAt, s.ledger.loc)
+	if err != nil {
+		return 0, fmt.Errorf("invalid occurredAt: %w", err)
+	}
+	receiptURL, err := normalizeAttachmentURL(input.ReceiptURL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid receiptUrl: %w", err)
+	}
+
+	return s.repo.CreateReimbursement(ctx, repository.CreateReimbursementInput{
+		RequesterID: input.RequesterID,
+		Amount:      amount,
+		Purpose:     purpose,
+		Category:    category,
+		OccurredAt:  occurredAt,
+		ReceiptURL:  receiptURL,
+	})
+}
+
+func (s *ReimbursementService) Get(ctx context.Context, id uint64) (model.Reimbursement, error) {
+	return s.repo.GetReimbursementByID(ctx, id)
+}
+
+func (s *ReimbursementService) List(ctx context.Context, input ListReimbursementsInput) (ListReimbursementsResult, error) {
+	page, pageSize, err := normalizePagination(input.Page, input.PageSize)
+	if err != nil {
+		return ListReimbursementsResult{}, err
+	}
+	status := model.ReimbursementStatus(strings.ToLower(strings.TrimSpace(input.Status)))
+	switch status {
+	case "", model.ReimbursementStatusPending, model.ReimbursementStatusApproved, model.ReimbursementStatusUnbooked, model.ReimbursementStatusRejected:
+	default:
+		return ListReimbursementsResult{}, ErrInvalidReimbursementStatus
+	}
+
+	filter := repository.ListReimbursementsFilter{
+		RequesterID: input.RequesterID,
+		Status:      status,
+		Limit:       pageSize,
+		Offset:      (page - 1) * pageSize,
+	}
+	items, err := s.repo.ListReimbursements(ctx, filter)
+	if err != nil {
+		return ListReimbursementsResult{}, err
+	}
+	total, err := s.repo.CountReimbursements(ctx, filter)
+	if err != nil {
+		return ListReimbursementsResult{}, err
+	}
+
+	return ListReimbursementsResult{
+		Items:      items,
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// Approve claims the reimbursement for the reviewer and then records it as an
+// expense, so a rejection racing the approval either wins or fails; it never
+// leaves an expense booked for a rejected request. The expense is submitted
+// under the admin who claimed the request, with the request id as its
+// idempotency key, so an approval interrupted after the claim, which leaves
+// the request unbooked, can be retried by any admin without booking twice.
+// Above the dual-approval threshold the reviewer must not be the requester,
+// and the expense is queued for a second admin like any other ledger write;
+// the request stays unbooked until that change is approved and the approval
+// is retried.
+func (s *ReimbursementService) Approve(ctx context.Context, input ApproveReimbursementInput) (LedgerWriteResult, error) {
+	if strings.TrimSpace(input.HandledBy) == "" {
+		return LedgerWriteResult{}, errors.New("handledBy is required")
+	}
+	item, err := s.repo.GetReimbursementByID(ctx, input.ReimbursementID)
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	if item.RequesterID == input.ReviewerID && s.approvals.requiresApproval(item.Amount) {
+		return LedgerWriteResult{}, ErrSameApprover
+	}
+
+	item, err = s.repo.ClaimReimbursement(ctx, input.ReimbursementID, input.ReviewerID)
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	reviewerID := input.ReviewerID
+	if item.ReviewedBy != nil {
+		reviewerID = *item.ReviewedBy
+	}
+
+	result, err := s.approvals.SubmitExpense(ctx, ExpenseInput{
+		ActorUserID: reviewerID,
+		Purpose:     fmt.Sprintf("[reimbursement #%d] %s", item.ID, item.Purpose),
+		Amount:      item.Amount.String(),
+		HandledBy:   input.HandledBy,
+		OccurredAt:  item.OccurredAt.Format(time.RFC3339),
+		Category:    string(item.Category),
+		RequestID:   reimbursementRequestID(item.ID),
+	})
+	if err != nil {
+		return LedgerWriteResult{}, err
+	}
+	if result.Pending {
+		return result, nil
+	}
+	if result.EntryID == 0 {
+		return LedgerWriteResult{}, ErrReimbursementChangeRejected
+	}
+
+	if err := s.repo.SetReimbursementEntry(ctx, item.ID, result.EntryID); err != nil {
+		return LedgerWriteResult{}, err
+	}
+	return result, nil
+}
+
+func (s *ReimbursementService) Reject(ctx context.Context, id, reviewerID uint64, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("reason is required")
+	}
+	return s.repo.MarkReimbursementRejected(ctx, id, reviewerID, reason)
+}
+
+func reimbursementRequestID(id uint64) string {
+	return fmt.Sprintf("reimbursement-%d", id)
+}
+
+func normalizePagination(page, pageSize int) (int, int, error) {
+	if page == 0 {
+		page = defaultPage
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if page < 1 {
+		return 0, 0, ErrInvalidPage
+	}
+	if pageSize < 1 || pageSize > maxPageSize {
+		return 0, 0, ErrInvalidPageSize
+	}
+	return page, pageSize, nil
+}
+
+// normalizeAttachmentURL accepts an optional http(s) link to an uploaded image.
+func normalizeAttachmentURL(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return "", nil
+	}
+	if len(value) > maxAttachmentURLLen {
+		return "", fmt.Errorf("must be at most %d characters", maxAttachmentURLLen)
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", errors.New("must be an http or https URL")
+	}
+	return value, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"propets/backend/internal/model"
+	"propets/backend/internal/repository"
+)
+
+// reimbursementRepository holds one request and claims it like the SQL
+// repository does.
+type reimbursementRepository struct {
+	repository.ReimbursementRepository
+	item model.Reimbursement
+}
+
+func (r *reimbursementRepository) GetReimbursementByID(context.Context, uint64) (model.Reimbursement, error) {
+	return r.item, nil
+}
+
+func (r *reimbursementRepository) ClaimReimbursement(_ context.Context, _, reviewerID uint64) (model.Reimbursement, error) {
+	r.item.Status = model.ReimbursementStatusUnbooked
+	r.item.ReviewedBy = &reviewerID
+	return r.item, nil
+}
+
+func (r *reimbursementRepository) SetReimbursementEntry(_ context.Context, _, entryID uint64) error {
+	r.item.Status = model.ReimbursementStatusApproved
+	r.item.EntryID = &entryID
+	return nil
+}
+
+// queueRepository records the changes queued for a second admin.
+type queueRepository struct {
+	repository.LedgerChangeRepository
+	queued []repository.CreateLedgerChangeInput
+}
+
+func (r *queueRepository) CreateChange(_ context.Context, input repository.CreateLedgerChangeInput) (uint64, bool, error) {
+	r.queued = append(r.queued, input)
+	return uint64(len(r.queued)), false, nil
+}
+
+func TestApproveReimbursement(t *testing.T) {
+	cases := []struct {
+		name       string
+		amount     model.Money
+		wantQueued bool
+	}{
+		{name: "at the threshold books at once", amount: 10000},
+		{name: "above the threshold waits for a second admin", amount: 10001, wantQueued: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries := &bookingRepository{byRequestID: make(map[string]uint64)}
+			changes := &queueRepository{}
+			ledger := NewLedgerService(entries, time.UTC)
+			approvals := NewLedgerApprovalService(changes, entries, ledger, 10000)
+			repo := &reimbursementRepository{item: model.Reimbursement{
+				ID:          5,
+				RequesterID: 10,
+				Amount:      tc.amount,
+				Purpose:     "vet bill",
+				Category:    model.ExpenseCategoryOther,
+				OccurredAt:  time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+				Status:      model.ReimbursementStatusPending,
+			}}
+			svc := NewReimbursementService(repo, ledger, approvals)
+
+			result, err := svc.Approve(context.Background(), ApproveReimbursementInput{ReimbursementID: 5, ReviewerID: 20, HandledBy: "member"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Pending != tc.wantQueued {
+				t.Errorf("pending = %v, want %v", result.Pending, tc.wantQueued)
+			}
+			if tc.wantQueued {
+				if len(entries.booked) != 0 || len(changes.queued) != 1 || changes.queued[0].RequestedBy != 20 {
+					t.Errorf("booked %v, queued %+v; want one change requested by the reviewer", entries.booked, changes.queued)
+				}
+				if repo.item.Status != model.ReimbursementStatusUnbooked {
+					t.Errorf("status = %q, want unbooked", repo.item.Status)
+				}
+				return
+			}
+			if len(entries.booked) != 1 || len(changes.queued) != 0 {
+				t.Errorf("booked %v, queued %+v; want one entry", entries.booked, changes.queued)
+			}
+			if repo.item.EntryID == nil || *repo.item.EntryID != result.EntryID {
+				t.Errorf("entry id = %v, want %d", repo.item.EntryID, result.EntryID)
+			}
+		})
+	}
+}
+
+func TestApproveOwnReimbursementAboveThreshold(t *testing.T) {
+	entries := &bookingRepository{byRequestID: make(map[string]uint64)}
+	ledger := NewLedgerService(entries, time.UTC)
+	repo := &reimbursementRepository{item: model.Reimbursement{ID: 5, RequesterID: 20, Amount: 10001}}
+	svc := NewReimbursementService(repo, ledger, NewLedgerApprovalService(&queueRepository{}, entries, ledger, 10000))
+
+	if _, err := svc.Approve(context.Background(), ApproveReimbursementInput{ReimbursementID: 5, ReviewerID: 20, HandledBy: "me"}); !errors.Is(err, ErrSameApprover) {
+		t.Errorf("Approve error = %v, want ErrSameApprover", err)
+	}
+}
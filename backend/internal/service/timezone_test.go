@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseOccurredAtMonthBoundaries(t *testing.T) {
+	cases := []struct {
+		zone      string
+		raw       string
+		wantUTC   string
+		wantMonth string
+	}{
+		// Plain dates are midnight in the organization zone.
+		{"Asia/Shanghai", "2024-02-01", "2024-01-31T16:00:00Z", "2024-02"},
+		{"America/Los_Angeles", "2024-02-01", "2024-02-01T08:00:00Z", "2024-02"},
+		{"Pacific/Kiritimati", "2024-03-01", "2024-02-29T10:00:00Z", "2024-03"},
+		{"Europe/London", "2024-04-01", "2024-03-31T23:00:00Z", "2024-04"},
+		// Timestamps keep their instant and are grouped by the organization's
+		// calendar, not the offset they were sent with.
+		{"America/Los_Angeles", "2024-01-31T23:30:00-08:00", "2024-02-01T07:30:00Z", "2024-01"},
+		{"Asia/Shanghai", "2024-01-31T23:30:00-08:00", "2024-02-01T07:30:00Z", "2024-02"},
+		{"Asia/Tokyo", "2024-12-31T15:00:00Z", "2024-12-31T15:00:00Z", "2025-01"},
+		{"UTC", "2024-12-31T15:00:00Z", "2024-12-31T15:00:00Z", "2024-12"},
+	}
+	for _, tc := range cases {
+		loc := mustLoadLocation(t, tc.zone)
+		got, err := parseOccurredAt(tc.raw, loc)
+		if err != nil {
+			t.Errorf("%s %q: %v", tc.zone, tc.raw, err)
+			continue
+		}
+		if utc := got.UTC().Format(time.RFC3339); utc != tc.wantUTC {
+			t.Errorf("%s %q: got %s, want %s", tc.zone, tc.raw, utc, tc.wantUTC)
+		}
+		if month := monthKeyOf(got, loc); month != tc.wantMonth {
+			t.Errorf("%s %q: month %s, want %s", tc.zone, tc.raw, month, tc.wantMonth)
+		}
+	}
+}
+
+func TestDateOfUsesOrganizationCalendar(t *testing.T) {
+	instant := time.Date(2024, time.January, 31, 20, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		zone string
+		want string
+	}{
+		{"Asia/Tokyo", "2024-02-01"},
+		{"America/Los_Angeles", "2024-01-31"},
+		{"UTC", "2024-01-31"},
+	}
+	for _, tc := range cases {
+		loc := mustLoadLocation(t, tc.zone)
+		got := dateOf(instant, loc)
+		if s := got.Format("2006-01-02"); s != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.zone, s, tc.want)
+		}
+		if got.Hour() != 0 || got.Location() != loc {
+			t.Errorf("%s: got %v, want midnight in zone", tc.zone, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package sms sends text messages to phone numbers. Real gateways plug in
+// behind Sender; the log and file senders are for local runs and tests.
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+type Sender interface {
+	Send(ctx context.Context, phone, message string) error
+}
+
+// LogSender writes messages to the process log instead of sending them.
+type LogSender struct{}
+
+func (LogSender) Send(_ context.Context, phone, message string) error {
+	log.Printf("sms to %s: %s", phone, message)
+	return nil
+}
+
+// FileSender appends one line per message to a file, which tests and local
+// setups can read the codes back from.
+type FileSender struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+func (s *FileSender) Send(_ context.Context, phone, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), phone, message); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// NewSender picks a sender by provider name: "log" (the default) or "file",
+// which writes to path.
+func NewSender(provider, path string) (Sender, error) {
+	switch provider {
+	case "", "log":
+		return LogSender{}, nil
+	case "file":
+		if path == "" {
+			return nil, fmt.Errorf("sms provider file needs a path")
+		}
+		return NewFileSender(path), nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", provider)
+	}
+}
@@ -0,0 +1,44 @@
+package sms
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSenderAppendsMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sms.log")
+	sender, err := NewSender("file", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"code 111111", "code 222222"} {
+		if err := sender.Send(context.Background(), "13800000000", msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), data)
+	}
+	if !strings.HasSuffix(lines[1], "\t13800000000\tcode 222222") {
+		t.Errorf("unexpected line %q", lines[1])
+	}
+}
+
+func TestNewSenderRejectsUnknownProvider(t *testing.T) {
+	if _, err := NewSender("carrier-pigeon", ""); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+	if _, err := NewSender("file", ""); err == nil {
+		t.Error("expected an error for a file sender without a path")
+	}
+}
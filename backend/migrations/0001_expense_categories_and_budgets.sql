@@ -0,0 +1,17 @@
+-- 支出分类与按分类的月度预算
+ALTER TABLE ledger_entries
+  ADD COLUMN category VARCHAR(32) NOT NULL DEFAULT '' AFTER description,
+  ADD KEY idx_ledger_month_category (month_key, entry_type, category);
+
+UPDATE ledger_entries SET category = 'other' WHERE entry_type = 'expense' AND category = '';
+
+CREATE TABLE IF NOT EXISTS ledger_category_budgets (
+  category VARCHAR(32) NOT NULL,
+  monthly_amount DECIMAL(12,2) NOT NULL,
+  updated_by BIGINT UNSIGNED NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (category),
+  CONSTRAINT chk_budget_amount_positive CHECK (monthly_amount > 0),
+  CONSTRAINT fk_ledger_category_budgets_updated_by
+    FOREIGN KEY (updated_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
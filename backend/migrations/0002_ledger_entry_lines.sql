@@ -0,0 +1,15 @@
+-- 支出拆分明细（一笔支出包含多项购买）
+CREATE TABLE IF NOT EXISTS ledger_entry_lines (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  entry_id BIGINT UNSIGNED NOT NULL,
+  line_no SMALLINT UNSIGNED NOT NULL,
+  description VARCHAR(200) NOT NULL,
+  amount DECIMAL(12,2) NOT NULL,
+  category VARCHAR(32) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ledger_entry_lines_entry_line (entry_id, line_no),
+  CONSTRAINT chk_ledger_line_amount_positive CHECK (amount > 0),
+  CONSTRAINT fk_ledger_entry_lines_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,26 @@
+-- 成员垫付报销申请
+CREATE TABLE IF NOT EXISTS reimbursement_requests (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  requester_id BIGINT UNSIGNED NOT NULL,
+  amount DECIMAL(12,2) NOT NULL,
+  purpose VARCHAR(200) NOT NULL,
+  category VARCHAR(32) NOT NULL DEFAULT 'other',
+  occurred_at DATETIME NOT NULL,
+  receipt_url VARCHAR(500) NOT NULL DEFAULT '',
+  status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+  reviewed_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  reviewed_at DATETIME NULL DEFAULT NULL,
+  review_note VARCHAR(500) NOT NULL DEFAULT '',
+  entry_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_reimbursement_requester_created (requester_id, created_at DESC, id DESC),
+  KEY idx_reimbursement_status_created (status, created_at DESC, id DESC),
+  CONSTRAINT chk_reimbursement_amount_positive CHECK (amount > 0),
+  CONSTRAINT fk_reimbursement_requester_id
+    FOREIGN KEY (requester_id) REFERENCES users(id),
+  CONSTRAINT fk_reimbursement_reviewed_by
+    FOREIGN KEY (reviewed_by) REFERENCES users(id),
+  CONSTRAINT fk_reimbursement_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,26 @@
+-- 成员上报捐款（待管理员确认）
+CREATE TABLE IF NOT EXISTS donation_reports (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  reporter_id BIGINT UNSIGNED NOT NULL,
+  donor VARCHAR(64) NOT NULL,
+  group_name VARCHAR(32) NOT NULL DEFAULT '',
+  amount DECIMAL(12,2) NOT NULL,
+  donated_at DATETIME NOT NULL,
+  screenshot_url VARCHAR(500) NOT NULL DEFAULT '',
+  status ENUM('pending', 'confirmed', 'rejected') NOT NULL DEFAULT 'pending',
+  reviewed_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  reviewed_at DATETIME NULL DEFAULT NULL,
+  review_note VARCHAR(500) NOT NULL DEFAULT '',
+  entry_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_donation_report_reporter_created (reporter_id, created_at DESC, id DESC),
+  KEY idx_donation_report_status_created (status, created_at DESC, id DESC),
+  CONSTRAINT chk_donation_report_amount_positive CHECK (amount > 0),
+  CONSTRAINT fk_donation_report_reporter_id
+    FOREIGN KEY (reporter_id) REFERENCES users(id),
+  CONSTRAINT fk_donation_report_reviewed_by
+    FOREIGN KEY (reviewed_by) REFERENCES users(id),
+  CONSTRAINT fk_donation_report_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,24 @@
+-- 周期性记账模板（按月自动生成流水）
+CREATE TABLE IF NOT EXISTS recurring_templates (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  entry_type ENUM('donation', 'expense') NOT NULL,
+  amount DECIMAL(12,2) NOT NULL,
+  donor VARCHAR(64) NOT NULL DEFAULT '',
+  purpose VARCHAR(200) NOT NULL DEFAULT '',
+  handled_by VARCHAR(64) NOT NULL DEFAULT '',
+  category VARCHAR(32) NOT NULL DEFAULT '',
+  frequency ENUM('monthly') NOT NULL DEFAULT 'monthly',
+  day_of_month TINYINT UNSIGNED NOT NULL,
+  start_date DATE NOT NULL,
+  end_date DATE NULL DEFAULT NULL,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  last_period CHAR(7) NULL DEFAULT NULL,
+  created_by BIGINT UNSIGNED NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_recurring_active (active, id),
+  CONSTRAINT chk_recurring_amount_positive CHECK (amount > 0),
+  CONSTRAINT chk_recurring_day_of_month CHECK (day_of_month BETWEEN 1 AND 28),
+  CONSTRAINT fk_recurring_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,27 @@
+-- 大额流水双人审批（待第二位管理员确认）
+CREATE TABLE IF NOT EXISTS ledger_change_requests (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  action ENUM('create', 'update', 'delete') NOT NULL,
+  entry_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  entry_type ENUM('donation', 'expense') NOT NULL,
+  amount DECIMAL(12,2) NOT NULL,
+  payload JSON NOT NULL,
+  status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+  requested_by BIGINT UNSIGNED NOT NULL,
+  request_id VARCHAR(128) NULL DEFAULT NULL,
+  reviewed_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  reviewed_at DATETIME NULL DEFAULT NULL,
+  review_note VARCHAR(500) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ledger_change_request_id (request_id),
+  KEY idx_ledger_change_status_created (status, created_at DESC, id DESC),
+  KEY idx_ledger_change_entry (entry_id, created_at, id),
+  CONSTRAINT chk_ledger_change_distinct_reviewer CHECK (reviewed_by IS NULL OR status = 'rejected' OR reviewed_by <> requested_by),
+  CONSTRAINT fk_ledger_change_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id),
+  CONSTRAINT fk_ledger_change_requested_by
+    FOREIGN KEY (requested_by) REFERENCES users(id),
+  CONSTRAINT fk_ledger_change_reviewed_by
+    FOREIGN KEY (reviewed_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,26 @@
+-- 流水评论与提问
+CREATE TABLE IF NOT EXISTS ledger_entry_comments (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  entry_id BIGINT UNSIGNED NOT NULL,
+  parent_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  author_id BIGINT UNSIGNED NOT NULL,
+  body VARCHAR(1000) NOT NULL,
+  hidden BOOLEAN NOT NULL DEFAULT FALSE,
+  hidden_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  resolved_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  resolved_at DATETIME NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_comment_entry_created (entry_id, created_at, id),
+  KEY idx_comment_open_threads (entry_id, parent_id, resolved_at, hidden),
+  CONSTRAINT fk_comment_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id),
+  CONSTRAINT fk_comment_parent_id
+    FOREIGN KEY (parent_id) REFERENCES ledger_entry_comments(id),
+  CONSTRAINT fk_comment_author_id
+    FOREIGN KEY (author_id) REFERENCES users(id),
+  CONSTRAINT fk_comment_hidden_by
+    FOREIGN KEY (hidden_by) REFERENCES users(id),
+  CONSTRAINT fk_comment_resolved_by
+    FOREIGN KEY (resolved_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,19 @@
+-- 流水自定义标签
+CREATE TABLE IF NOT EXISTS ledger_tags (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  name VARCHAR(32) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ledger_tags_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS ledger_entry_tags (
+  entry_id BIGINT UNSIGNED NOT NULL,
+  tag_id BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY (entry_id, tag_id),
+  KEY idx_entry_tags_tag (tag_id, entry_id),
+  CONSTRAINT fk_entry_tags_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id) ON DELETE CASCADE,
+  CONSTRAINT fk_entry_tags_tag_id
+    FOREIGN KEY (tag_id) REFERENCES ledger_tags(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
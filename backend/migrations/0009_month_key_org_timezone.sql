@@ -0,0 +1,16 @@
+-- 月份键改为直接取 occurred_at 的日期（occurred_at 已按 ORG_TIMEZONE 存储本地时间）
+-- 之前的 month_key 在本地时间上又加了 8 小时，月末 16:00 之后的流水会被算到下个月。
+-- 不支持在已有数据后直接修改 ORG_TIMEZONE：它同时决定连接串的 loc，应用写入的所有 DATETIME 都按该时区存储本地时间。
+-- 确需修改时，先停掉后端，再把下列所有 DATETIME 列换算到新时区（需 MySQL 已加载时区表），例如：
+--   UPDATE ledger_entries SET occurred_at = CONVERT_TZ(occurred_at, 'Asia/Shanghai', '<新时区>');
+--   ledger_entries.occurred_at（month_key 会随之自动重算）
+--   reimbursement_requests.occurred_at、reviewed_at；donation_reports.donated_at、reviewed_at
+--   ledger_change_requests.reviewed_at；ledger_entry_comments.resolved_at；users.reviewed_at
+--   refresh_tokens.expires_at、revoked_at、rotated_at、last_used_at
+--   password_reset_codes.expires_at、used_at；sms_codes.sent_at、expires_at、consumed_at
+--   login_attempts.window_start、last_failure_at、locked_until；invite_codes.expires_at、revoked_at
+-- 漏换算的列会让过期、锁定与限频判断整体偏移时差。
+ALTER TABLE ledger_entries
+  MODIFY COLUMN month_key CHAR(7) GENERATED ALWAYS AS (
+    DATE_FORMAT(occurred_at, '%Y-%m')
+  ) STORED;
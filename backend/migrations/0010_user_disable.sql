@@ -0,0 +1,4 @@
+-- 账号停用（停用后不能登录或刷新令牌）
+ALTER TABLE users
+  ADD COLUMN disabled_at TIMESTAMP NULL DEFAULT NULL AFTER role,
+  ADD KEY idx_users_role_disabled (role, disabled_at);
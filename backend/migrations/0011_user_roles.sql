@@ -0,0 +1,3 @@
+-- 细分角色：出纳（记账）、审计（只读含已删除与变更记录）、访客（只读）
+ALTER TABLE users
+  MODIFY COLUMN role ENUM('admin', 'treasurer', 'auditor', 'member', 'viewer') NOT NULL DEFAULT 'member';
@@ -0,0 +1,17 @@
+-- 管理员生成的一次性密码重置码
+CREATE TABLE IF NOT EXISTS password_reset_codes (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  code_hash CHAR(64) NOT NULL,
+  expires_at DATETIME NOT NULL,
+  used_at DATETIME NULL DEFAULT NULL,
+  created_by BIGINT UNSIGNED NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_reset_codes_user (user_id, used_at),
+  KEY idx_reset_codes_expires_at (expires_at),
+  CONSTRAINT fk_reset_codes_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id),
+  CONSTRAINT fk_reset_codes_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
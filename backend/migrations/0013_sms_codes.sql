@@ -0,0 +1,13 @@
+-- 短信验证码登录/注册（只存哈希，含过期时间与尝试次数）
+CREATE TABLE IF NOT EXISTS sms_codes (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  phone VARCHAR(20) NOT NULL,
+  code_hash CHAR(64) NOT NULL,
+  attempts TINYINT UNSIGNED NOT NULL DEFAULT 0,
+  sent_at DATETIME NOT NULL,
+  expires_at DATETIME NOT NULL,
+  consumed_at DATETIME NULL DEFAULT NULL,
+  PRIMARY KEY (id),
+  KEY idx_sms_codes_phone_sent (phone, sent_at),
+  KEY idx_sms_codes_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
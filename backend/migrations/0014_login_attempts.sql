@@ -0,0 +1,12 @@
+-- 登录失败计数与临时锁定（按手机号和客户端 IP）
+CREATE TABLE IF NOT EXISTS login_attempts (
+  scope ENUM('phone', 'ip') NOT NULL,
+  subject VARCHAR(64) NOT NULL,
+  failures INT UNSIGNED NOT NULL DEFAULT 0,
+  lockouts INT UNSIGNED NOT NULL DEFAULT 0,
+  window_start DATETIME NOT NULL,
+  last_failure_at DATETIME NOT NULL,
+  locked_until DATETIME NULL DEFAULT NULL,
+  PRIMARY KEY (scope, subject),
+  KEY idx_login_attempts_locked_until (locked_until)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,26 @@
+-- 刷新令牌族（同一次登录轮换出的令牌共享 family_id），用于检测令牌重放
+ALTER TABLE refresh_tokens
+  ADD COLUMN jti VARCHAR(64) NULL DEFAULT NULL AFTER token_hash,
+  ADD COLUMN family_id VARCHAR(64) NULL DEFAULT NULL AFTER jti,
+  ADD COLUMN rotated_at DATETIME NULL DEFAULT NULL AFTER revoked_at;
+
+-- 旧令牌各自成为一个族
+UPDATE refresh_tokens SET family_id = token_hash WHERE family_id IS NULL;
+
+ALTER TABLE refresh_tokens
+  MODIFY COLUMN family_id VARCHAR(64) NOT NULL,
+  ADD KEY idx_refresh_family_id (family_id);
+
+-- 安全事件（如刷新令牌重放）
+CREATE TABLE IF NOT EXISTS security_events (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  event_type VARCHAR(32) NOT NULL,
+  detail VARCHAR(500) NOT NULL DEFAULT '',
+  ip VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_security_events_user (user_id, id),
+  CONSTRAINT fk_security_events_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
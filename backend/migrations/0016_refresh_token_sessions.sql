@@ -0,0 +1,11 @@
+-- 记录每个刷新令牌的设备信息与最近使用时间，用于会话列表
+ALTER TABLE refresh_tokens
+  ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '' AFTER family_id,
+  ADD COLUMN ip VARCHAR(64) NOT NULL DEFAULT '' AFTER user_agent,
+  ADD COLUMN last_used_at DATETIME NULL DEFAULT NULL AFTER ip;
+
+-- 旧令牌以创建时间作为最近使用时间
+UPDATE refresh_tokens SET last_used_at = created_at WHERE last_used_at IS NULL;
+
+ALTER TABLE refresh_tokens
+  ADD KEY idx_refresh_user_active (user_id, revoked_at, expires_at);
@@ -0,0 +1,3 @@
+-- 令牌版本：角色变更、停用或改密时递增，使已签发的访问令牌立即失效
+ALTER TABLE users
+  ADD COLUMN token_version INT UNSIGNED NOT NULL DEFAULT 0 AFTER disabled_at;
@@ -0,0 +1,21 @@
+-- 邀请码注册：管理员生成邀请码（可选分组、使用次数、有效期与角色），并记录用户注册时使用的邀请码
+CREATE TABLE IF NOT EXISTS invite_codes (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  code VARCHAR(32) NOT NULL,
+  group_name VARCHAR(64) NOT NULL DEFAULT '',
+  role ENUM('admin', 'treasurer', 'auditor', 'member', 'viewer') NOT NULL DEFAULT 'member',
+  max_uses INT UNSIGNED NULL DEFAULT NULL,
+  used_count INT UNSIGNED NOT NULL DEFAULT 0,
+  expires_at DATETIME NULL DEFAULT NULL,
+  revoked_at DATETIME NULL DEFAULT NULL,
+  created_by BIGINT UNSIGNED NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_invite_codes_code (code),
+  CONSTRAINT fk_invite_codes_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+ALTER TABLE users
+  ADD COLUMN invite_code_id BIGINT UNSIGNED NULL DEFAULT NULL AFTER token_version,
+  ADD KEY idx_users_invite_code (invite_code_id);
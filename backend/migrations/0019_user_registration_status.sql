@@ -0,0 +1,6 @@
+-- 注册审核：开放注册时新账号处于待审核状态，管理员批准后才能登录
+ALTER TABLE users
+  ADD COLUMN status ENUM('active', 'pending', 'rejected') NOT NULL DEFAULT 'active' AFTER role,
+  ADD COLUMN reviewed_by BIGINT UNSIGNED NULL DEFAULT NULL AFTER status,
+  ADD COLUMN reviewed_at DATETIME NULL DEFAULT NULL AFTER reviewed_by,
+  ADD KEY idx_users_status (status, id);
@@ -0,0 +1,22 @@
+-- 用户资料：显示名、微信昵称、头像与所属群组
+ALTER TABLE users
+  ADD COLUMN display_name VARCHAR(32) NOT NULL DEFAULT '' AFTER invite_code_id,
+  ADD COLUMN wechat_nickname VARCHAR(32) NOT NULL DEFAULT '' AFTER display_name,
+  ADD COLUMN avatar_url VARCHAR(512) NOT NULL DEFAULT '' AFTER wechat_nickname;
+
+CREATE TABLE IF NOT EXISTS user_groups (
+  user_id BIGINT UNSIGNED NOT NULL,
+  group_name VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, group_name),
+  KEY idx_user_groups_name (group_name),
+  CONSTRAINT fk_user_groups_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- 已用带分组的邀请码注册的用户加入对应群组
+INSERT IGNORE INTO user_groups (user_id, group_name)
+SELECT u.id, c.group_name
+FROM users u
+JOIN invite_codes c ON c.id = u.invite_code_id
+WHERE c.group_name <> '';
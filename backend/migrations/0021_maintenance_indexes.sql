@@ -0,0 +1,6 @@
+-- 定期清理过期/已撤销的刷新令牌与过期幂等键所需的索引
+ALTER TABLE refresh_tokens
+  ADD KEY idx_refresh_revoked_at (revoked_at);
+
+ALTER TABLE ledger_idempotency_keys
+  ADD KEY idx_ledger_idempotency_created_at (created_at);
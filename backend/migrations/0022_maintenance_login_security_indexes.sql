@@ -0,0 +1,6 @@
+-- 定期清理失效的登录失败计数与过期安全事件所需的索引
+ALTER TABLE login_attempts
+  ADD KEY idx_login_attempts_last_failure_at (last_failure_at);
+
+ALTER TABLE security_events
+  ADD KEY idx_security_events_created_at (created_at);
@@ -2,10 +2,32 @@ CREATE TABLE IF NOT EXISTS users (
   id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
   phone VARCHAR(20) NOT NULL,
   password_hash VARCHAR(255) NOT NULL,
-  role ENUM('admin', 'member') NOT NULL DEFAULT 'member',
+  role ENUM('admin', 'treasurer', 'auditor', 'member', 'viewer') NOT NULL DEFAULT 'member',
+  status ENUM('active', 'pending', 'rejected') NOT NULL DEFAULT 'active',
+  reviewed_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  reviewed_at DATETIME NULL DEFAULT NULL,
+  disabled_at TIMESTAMP NULL DEFAULT NULL,
+  token_version INT UNSIGNED NOT NULL DEFAULT 0,
+  invite_code_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  display_name VARCHAR(32) NOT NULL DEFAULT '',
+  wechat_nickname VARCHAR(32) NOT NULL DEFAULT '',
+  avatar_url VARCHAR(512) NOT NULL DEFAULT '',
   created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   PRIMARY KEY (id),
-  UNIQUE KEY uk_users_phone (phone)
+  UNIQUE KEY uk_users_phone (phone),
+  KEY idx_users_role_disabled (role, disabled_at),
+  KEY idx_users_invite_code (invite_code_id),
+  KEY idx_users_status (status, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS user_groups (
+  user_id BIGINT UNSIGNED NOT NULL,
+  group_name VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, group_name),
+  KEY idx_user_groups_name (group_name),
+  CONSTRAINT fk_user_groups_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 CREATE TABLE IF NOT EXISTS ledger_entries (
@@ -15,8 +37,9 @@ CREATE TABLE IF NOT EXISTS ledger_entries (
   amount DECIMAL(12,2) NOT NULL,
   occurred_at DATETIME NOT NULL,
   description VARCHAR(500) NOT NULL DEFAULT '',
+  category VARCHAR(32) NOT NULL DEFAULT '',
   month_key CHAR(7) GENERATED ALWAYS AS (
-    DATE_FORMAT(occurred_at + INTERVAL 8 HOUR, '%Y-%m')
+    DATE_FORMAT(occurred_at, '%Y-%m')
   ) STORED,
   deleted_at TIMESTAMP NULL DEFAULT NULL,
   deleted_by BIGINT UNSIGNED NULL DEFAULT NULL,
@@ -27,6 +50,7 @@ CREATE TABLE IF NOT EXISTS ledger_entries (
   KEY idx_ledger_type_month (entry_type, month_key, created_at DESC, id DESC),
   KEY idx_ledger_created (created_at DESC, id DESC),
   KEY idx_ledger_deleted (deleted_at),
+  KEY idx_ledger_month_category (month_key, entry_type, category),
   CONSTRAINT chk_ledger_amount_positive CHECK (amount > 0),
   CONSTRAINT fk_ledger_entries_user_id
     FOREIGN KEY (user_id) REFERENCES users(id),
@@ -38,13 +62,22 @@ CREATE TABLE IF NOT EXISTS refresh_tokens (
   id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
   user_id BIGINT UNSIGNED NOT NULL,
   token_hash CHAR(64) NOT NULL,
+  jti VARCHAR(64) NULL DEFAULT NULL,
+  family_id VARCHAR(64) NOT NULL,
+  user_agent VARCHAR(255) NOT NULL DEFAULT '',
+  ip VARCHAR(64) NOT NULL DEFAULT '',
+  last_used_at DATETIME NULL DEFAULT NULL,
   expires_at DATETIME NOT NULL,
   revoked_at DATETIME NULL,
+  rotated_at DATETIME NULL DEFAULT NULL,
   created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   PRIMARY KEY (id),
   UNIQUE KEY uq_refresh_token_hash (token_hash),
   KEY idx_refresh_user_id (user_id),
+  KEY idx_refresh_family_id (family_id),
   KEY idx_refresh_expires_at (expires_at),
+  KEY idx_refresh_user_active (user_id, revoked_at, expires_at),
+  KEY idx_refresh_revoked_at (revoked_at),
   CONSTRAINT fk_refresh_tokens_user_id
     FOREIGN KEY (user_id) REFERENCES users(id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -57,8 +90,257 @@ CREATE TABLE IF NOT EXISTS ledger_idempotency_keys (
   created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   PRIMARY KEY (request_id),
   KEY idx_ledger_idempotency_created_by (created_by, created_at DESC),
+  KEY idx_ledger_idempotency_created_at (created_at),
   CONSTRAINT fk_ledger_idempotency_user_id
     FOREIGN KEY (created_by) REFERENCES users(id),
   CONSTRAINT fk_ledger_idempotency_entry_id
     FOREIGN KEY (entry_id) REFERENCES ledger_entries(id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS ledger_category_budgets (
+  category VARCHAR(32) NOT NULL,
+  monthly_amount DECIMAL(12,2) NOT NULL,
+  updated_by BIGINT UNSIGNED NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (category),
+  CONSTRAINT chk_budget_amount_positive CHECK (monthly_amount > 0),
+  CONSTRAINT fk_ledger_category_budgets_updated_by
+    FOREIGN KEY (updated_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS ledger_entry_lines (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  entry_id BIGINT UNSIGNED NOT NULL,
+  line_no SMALLINT UNSIGNED NOT NULL,
+  description VARCHAR(200) NOT NULL,
+  amount DECIMAL(12,2) NOT NULL,
+  category VARCHAR(32) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ledger_entry_lines_entry_line (entry_id, line_no),
+  CONSTRAINT chk_ledger_line_amount_positive CHECK (amount > 0),
+  CONSTRAINT fk_ledger_entry_lines_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS reimbursement_requests (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  requester_id BIGINT UNSIGNED NOT NULL,
+  amount DECIMAL(12,2) NOT NULL,
+  purpose VARCHAR(200) NOT NULL,
+  category VARCHAR(32) NOT NULL DEFAULT 'other',
+  occurred_at DATETIME NOT NULL,
+  receipt_url VARCHAR(500) NOT NULL DEFAULT '',
+  status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+  reviewed_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  reviewed_at DATETIME NULL DEFAULT NULL,
+  review_note VARCHAR(500) NOT NULL DEFAULT '',
+  entry_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_reimbursement_requester_created (requester_id, created_at DESC, id DESC),
+  KEY idx_reimbursement_status_created (status, created_at DESC, id DESC),
+  CONSTRAINT chk_reimbursement_amount_positive CHECK (amount > 0),
+  CONSTRAINT fk_reimbursement_requester_id
+    FOREIGN KEY (requester_id) REFERENCES users(id),
+  CONSTRAINT fk_reimbursement_reviewed_by
+    FOREIGN KEY (reviewed_by) REFERENCES users(id),
+  CONSTRAINT fk_reimbursement_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS donation_reports (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  reporter_id BIGINT UNSIGNED NOT NULL,
+  donor VARCHAR(64) NOT NULL,
+  group_name VARCHAR(32) NOT NULL DEFAULT '',
+  amount DECIMAL(12,2) NOT NULL,
+  donated_at DATETIME NOT NULL,
+  screenshot_url VARCHAR(500) NOT NULL DEFAULT '',
+  status ENUM('pending', 'confirmed', 'rejected') NOT NULL DEFAULT 'pending',
+  reviewed_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  reviewed_at DATETIME NULL DEFAULT NULL,
+  review_note VARCHAR(500) NOT NULL DEFAULT '',
+  entry_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_donation_report_reporter_created (reporter_id, created_at DESC, id DESC),
+  KEY idx_donation_report_status_created (status, created_at DESC, id DESC),
+  CONSTRAINT chk_donation_report_amount_positive CHECK (amount > 0),
+  CONSTRAINT fk_donation_report_reporter_id
+    FOREIGN KEY (reporter_id) REFERENCES users(id),
+  CONSTRAINT fk_donation_report_reviewed_by
+    FOREIGN KEY (reviewed_by) REFERENCES users(id),
+  CONSTRAINT fk_donation_report_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS recurring_templates (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  entry_type ENUM('donation', 'expense') NOT NULL,
+  amount DECIMAL(12,2) NOT NULL,
+  donor VARCHAR(64) NOT NULL DEFAULT '',
+  purpose VARCHAR(200) NOT NULL DEFAULT '',
+  handled_by VARCHAR(64) NOT NULL DEFAULT '',
+  category VARCHAR(32) NOT NULL DEFAULT '',
+  frequency ENUM('monthly') NOT NULL DEFAULT 'monthly',
+  day_of_month TINYINT UNSIGNED NOT NULL,
+  start_date DATE NOT NULL,
+  end_date DATE NULL DEFAULT NULL,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  last_period CHAR(7) NULL DEFAULT NULL,
+  created_by BIGINT UNSIGNED NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_recurring_active (active, id),
+  CONSTRAINT chk_recurring_amount_positive CHECK (amount > 0),
+  CONSTRAINT chk_recurring_day_of_month CHECK (day_of_month BETWEEN 1 AND 28),
+  CONSTRAINT fk_recurring_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS ledger_change_requests (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  action ENUM('create', 'update', 'delete') NOT NULL,
+  entry_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  entry_type ENUM('donation', 'expense') NOT NULL,
+  amount DECIMAL(12,2) NOT NULL,
+  payload JSON NOT NULL,
+  status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+  requested_by BIGINT UNSIGNED NOT NULL,
+  request_id VARCHAR(128) NULL DEFAULT NULL,
+  reviewed_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  reviewed_at DATETIME NULL DEFAULT NULL,
+  review_note VARCHAR(500) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ledger_change_request_id (request_id),
+  KEY idx_ledger_change_status_created (status, created_at DESC, id DESC),
+  KEY idx_ledger_change_entry (entry_id, created_at, id),
+  CONSTRAINT chk_ledger_change_distinct_reviewer CHECK (reviewed_by IS NULL OR status = 'rejected' OR reviewed_by <> requested_by),
+  CONSTRAINT fk_ledger_change_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id),
+  CONSTRAINT fk_ledger_change_requested_by
+    FOREIGN KEY (requested_by) REFERENCES users(id),
+  CONSTRAINT fk_ledger_change_reviewed_by
+    FOREIGN KEY (reviewed_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS ledger_entry_comments (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  entry_id BIGINT UNSIGNED NOT NULL,
+  parent_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  author_id BIGINT UNSIGNED NOT NULL,
+  body VARCHAR(1000) NOT NULL,
+  hidden BOOLEAN NOT NULL DEFAULT FALSE,
+  hidden_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  resolved_by BIGINT UNSIGNED NULL DEFAULT NULL,
+  resolved_at DATETIME NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_comment_entry_created (entry_id, created_at, id),
+  KEY idx_comment_open_threads (entry_id, parent_id, resolved_at, hidden),
+  CONSTRAINT fk_comment_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id),
+  CONSTRAINT fk_comment_parent_id
+    FOREIGN KEY (parent_id) REFERENCES ledger_entry_comments(id),
+  CONSTRAINT fk_comment_author_id
+    FOREIGN KEY (author_id) REFERENCES users(id),
+  CONSTRAINT fk_comment_hidden_by
+    FOREIGN KEY (hidden_by) REFERENCES users(id),
+  CONSTRAINT fk_comment_resolved_by
+    FOREIGN KEY (resolved_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS ledger_tags (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  name VARCHAR(32) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ledger_tags_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS ledger_entry_tags (
+  entry_id BIGINT UNSIGNED NOT NULL,
+  tag_id BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY (entry_id, tag_id),
+  KEY idx_entry_tags_tag (tag_id, entry_id),
+  CONSTRAINT fk_entry_tags_entry_id
+    FOREIGN KEY (entry_id) REFERENCES ledger_entries(id) ON DELETE CASCADE,
+  CONSTRAINT fk_entry_tags_tag_id
+    FOREIGN KEY (tag_id) REFERENCES ledger_tags(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS password_reset_codes (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NOT NULL,
+  code_hash CHAR(64) NOT NULL,
+  expires_at DATETIME NOT NULL,
+  used_at DATETIME NULL DEFAULT NULL,
+  created_by BIGINT UNSIGNED NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_reset_codes_user (user_id, used_at),
+  KEY idx_reset_codes_expires_at (expires_at),
+  CONSTRAINT fk_reset_codes_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id),
+  CONSTRAINT fk_reset_codes_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS sms_codes (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  phone VARCHAR(20) NOT NULL,
+  code_hash CHAR(64) NOT NULL,
+  attempts TINYINT UNSIGNED NOT NULL DEFAULT 0,
+  sent_at DATETIME NOT NULL,
+  expires_at DATETIME NOT NULL,
+  consumed_at DATETIME NULL DEFAULT NULL,
+  PRIMARY KEY (id),
+  KEY idx_sms_codes_phone_sent (phone, sent_at),
+  KEY idx_sms_codes_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+  scope ENUM('phone', 'ip') NOT NULL,
+  subject VARCHAR(64) NOT NULL,
+  failures INT UNSIGNED NOT NULL DEFAULT 0,
+  lockouts INT UNSIGNED NOT NULL DEFAULT 0,
+  window_start DATETIME NOT NULL,
+  last_failure_at DATETIME NOT NULL,
+  locked_until DATETIME NULL DEFAULT NULL,
+  PRIMARY KEY (scope, subject),
+  KEY idx_login_attempts_locked_until (locked_until),
+  KEY idx_login_attempts_last_failure_at (last_failure_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS security_events (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  user_id BIGINT UNSIGNED NULL DEFAULT NULL,
+  event_type VARCHAR(32) NOT NULL,
+  detail VARCHAR(500) NOT NULL DEFAULT '',
+  ip VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  KEY idx_security_events_user (user_id, id),
+  KEY idx_security_events_created_at (created_at),
+  CONSTRAINT fk_security_events_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS invite_codes (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  code VARCHAR(32) NOT NULL,
+  group_name VARCHAR(64) NOT NULL DEFAULT '',
+  role ENUM('admin', 'treasurer', 'auditor', 'member', 'viewer') NOT NULL DEFAULT 'member',
+  max_uses INT UNSIGNED NULL DEFAULT NULL,
+  used_count INT UNSIGNED NOT NULL DEFAULT 0,
+  expires_at DATETIME NULL DEFAULT NULL,
+  revoked_at DATETIME NULL DEFAULT NULL,
+  created_by BIGINT UNSIGNED NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY uq_invite_codes_code (code),
+  CONSTRAINT fk_invite_codes_created_by
+    FOREIGN KEY (created_by) REFERENCES users(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
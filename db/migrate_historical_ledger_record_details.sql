@@ -1553,6 +1553,14 @@ VALUES
   (@import_user_id, 'expense', 231.00, '2026-01-23 09:02:01', '[历史明细迁移] 2026-01.md L147 大胖狸花做节育100，买皮肤病要131，1月23日'),
   (@import_user_id, 'donation', 216.00, '2026-01-04 23:50:00', '[历史明细迁移][自动调整] 2026-01.md 收入差额补齐 216.00');
 
+-- 历史支出未区分分类，统一归入 other
+UPDATE ledger_entries
+SET category = 'other'
+WHERE user_id = @import_user_id
+  AND entry_type = 'expense'
+  AND category = ''
+  AND description LIKE '[历史明细迁移]%';
+
 COMMIT;
 
 -- 可选核对：